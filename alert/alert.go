@@ -0,0 +1,150 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type Severity int
+
+const (
+	INFO Severity = iota
+	WARNING
+	ERROR
+	CRITICAL
+)
+
+func (s Severity) String() string {
+	switch s {
+	case INFO:
+		return "info"
+	case WARNING:
+		return "warning"
+	case ERROR:
+		return "error"
+	case CRITICAL:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// empty string means INFO, every alert is sent
+func ParseSeverity(severity string) (Severity, error) {
+	switch strings.ToLower(severity) {
+	case "", "info":
+		return INFO, nil
+	case "warning":
+		return WARNING, nil
+	case "error":
+		return ERROR, nil
+	case "critical":
+		return CRITICAL, nil
+	default:
+		msg := fmt.Sprintf("unknown severity: %s", severity)
+		return INFO, errors.New(msg)
+	}
+}
+
+// Alerter is a sink of alerts
+type Alerter interface {
+	Name() string
+	// alerts lower than this are not sent
+	MinSeverity() Severity
+	Send(title string, severity Severity, msg string) error
+}
+
+// EventAlerter receives every notification with its key instead of grouped messages,
+// so the sink can resolve what it triggered, e.g. pagerduty incidents
+type EventAlerter interface {
+	Alerter
+	SendEvent(title string, event Event) error
+}
+
+// Event is a notification of an alert condition
+type Event struct {
+	DedupKey string
+	Severity Severity
+	Msg      string
+	// true if the condition is resolved
+	Resolved bool
+}
+
+var (
+	title    string
+	alerters []Alerter
+	queue    chan func()
+)
+
+func SetAlerters(t string, a ...Alerter) {
+	if len(a) == 0 {
+		return
+	}
+
+	// set alerters (singleton)
+	title = t
+	alerters = a
+	queue = make(chan func(), 1024)
+
+	// thread safe
+	go func() {
+		for send := range queue {
+			send()
+		}
+	}()
+}
+
+func enqueue(alerter Alerter, severity Severity, msg string) {
+	queue <- func() {
+		err := alerter.Send(title, severity, msg)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to send alert to %s", alerter.Name())
+			log.Error().Stack().Err(err).Msg("")
+		}
+	}
+}
+
+func enqueueEvent(alerter EventAlerter, event Event) {
+	queue <- func() {
+		err := alerter.SendEvent(title, event)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to send alert to %s", alerter.Name())
+			log.Error().Stack().Err(err).Msg("")
+		}
+	}
+}
+
+// Send sends a one-off alert, the same msg of the key is sent again only after reminderInterval,
+// it's not sent to EventAlerters which would keep the incident open, use Fire and Resolve for conditions
+func Send(key Key, severity Severity, msg string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	now := time.Now().UTC()
+
+	if isSilenced(key, now) {
+		return
+	}
+
+	window := reminderInterval
+	if window == 0 {
+		window = SEND_DEDUP_WINDOW
+	}
+	for sentKey, sentAt := range sent {
+		if now.Sub(sentAt) >= window {
+			delete(sent, sentKey)
+		}
+	}
+
+	sentKey := fmt.Sprintf("%s\n%s", key.String(), msg)
+	if _, ok := sent[sentKey]; ok {
+		return
+	}
+	sent[sentKey] = now
+
+	enqueueNotification(notification{key: key, severity: severity, msg: msg, oneOff: true})
+}
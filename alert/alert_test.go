@@ -0,0 +1,136 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		severity string
+
+		expected Severity
+		err      bool
+	}{
+		{severity: "", expected: INFO},
+		{severity: "info", expected: INFO},
+		{severity: "Warning", expected: WARNING},
+		{severity: "error", expected: ERROR},
+		{severity: "CRITICAL", expected: CRITICAL},
+		{severity: "fatal", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.severity, func(t *testing.T) {
+			severity, err := ParseSeverity(test.severity)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+			if severity != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, severity)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+
+		msg string
+		max int
+
+		expected string
+	}{
+		{name: "short", msg: "abc", max: 5, expected: "abc"},
+		{name: "exact", msg: "abcde", max: 5, expected: "abcde"},
+		{name: "long", msg: "abcdefgh", max: 5, expected: "ab..."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := truncate(test.msg, test.max)
+			if result != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+// sink server which records the body of the last request
+func newSinkServer(t *testing.T, status int) (*httptest.Server, *map[string]any) {
+	body := make(map[string]any)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &body
+}
+
+func TestSinks(t *testing.T) {
+	tests := []struct {
+		name string
+
+		newAlerter func(url string) Alerter
+		status     int
+
+		// body field => substring of the value
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name:       "slack",
+			newAlerter: func(url string) Alerter { return NewSlack(url, INFO) },
+			status:     http.StatusOK,
+			expected:   map[string]string{"text": "*ibcmon*\n[warning] channel is closed"},
+		},
+		{
+			name:       "discord",
+			newAlerter: func(url string) Alerter { return NewDiscord(url, INFO) },
+			status:     http.StatusNoContent,
+			expected:   map[string]string{"content": "**ibcmon**\n[warning] channel is closed"},
+		},
+		{
+			name:       "webhook",
+			newAlerter: func(url string) Alerter { return NewWebhook(url, INFO) },
+			status:     http.StatusOK,
+			expected: map[string]string{
+				"title":    "ibcmon",
+				"severity": "warning",
+				"message":  "channel is closed",
+			},
+		},
+		{
+			name:       "rejected",
+			newAlerter: func(url string) Alerter { return NewWebhook(url, INFO) },
+			status:     http.StatusBadRequest,
+			err:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, body := newSinkServer(t, test.status)
+
+			err := test.newAlerter(server.URL).Send("ibcmon", WARNING, "channel is closed")
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+
+			for field, expected := range test.expected {
+				value, _ := (*body)[field].(string)
+				if !strings.Contains(value, expected) {
+					t.Fatalf("expected %s to contain %q, got %q", field, expected, value)
+				}
+			}
+		})
+	}
+}
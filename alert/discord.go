@@ -0,0 +1,38 @@
+package alert
+
+import (
+	"fmt"
+)
+
+// Discord sends alerts to a channel webhook
+type Discord struct {
+	webhookURL  string
+	minSeverity Severity
+}
+type DiscordBody struct {
+	Content string `json:"content"`
+}
+
+func NewDiscord(webhookURL string, minSeverity Severity) *Discord {
+	return &Discord{
+		webhookURL:  webhookURL,
+		minSeverity: minSeverity,
+	}
+}
+
+func (discord *Discord) Name() string {
+	return "discord"
+}
+
+func (discord *Discord) MinSeverity() Severity {
+	return discord.minSeverity
+}
+
+func (discord *Discord) Send(title string, severity Severity, msg string) error {
+	body := DiscordBody{
+		// discord rejects messages longer than 2000 characters
+		Content: truncate(fmt.Sprintf("**%s**\n[%s] %s", title, severity, msg), 2000),
+	}
+
+	return postJSON(discord.webhookURL, body)
+}
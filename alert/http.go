@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var client = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+func postJSON(url string, body any) error {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode alert")
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		url,
+		bytes.NewBuffer(bodyBytes),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	if !(200 <= resp.StatusCode && resp.StatusCode <= 299) {
+		msg := fmt.Sprintf("unexpected response: %s", resp.Status)
+		return errors.New(msg)
+	}
+
+	return nil
+}
+
+// some sinks reject too long messages
+func truncate(msg string, max int) string {
+	if len(msg) <= max {
+		return msg
+	}
+	return msg[:max-3] + "..."
+}
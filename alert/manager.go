@@ -0,0 +1,229 @@
+package alert
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key identifies an alert condition, empty fields are not related with the condition
+type Key struct {
+	ChainId             string
+	CounterpartyChainId string
+	ClientId            string
+	ChannelId           string
+	PortId              string
+	// name of the failing job, empty for conditions of ibc
+	Job string
+
+	Condition string
+}
+
+func (key Key) String() string {
+	if key.Job != "" {
+		return fmt.Sprintf("%s: %s", key.Job, key.Condition)
+	}
+	return fmt.Sprintf(
+		"%s(%s/%s/%s) => %s: %s",
+		key.ChainId, key.ClientId, key.ChannelId, key.PortId,
+		key.CounterpartyChainId, key.Condition,
+	)
+}
+
+type (
+	firingAlert struct {
+		severity Severity
+		msg      string
+
+		firedAt  time.Time
+		lastSent time.Time
+		// false if it was silenced when fired
+		notified bool
+	}
+	notification struct {
+		key      Key
+		severity Severity
+		msg      string
+		resolved bool
+		// sent by Send, nothing resolves it
+		oneOff bool
+	}
+)
+
+var (
+	mutex sync.Mutex
+
+	// alerts are grouped for this duration and sent as one message
+	groupWait time.Duration
+	// firing alerts are sent again after this duration, 0 means never
+	reminderInterval time.Duration
+
+	firing  = make(map[Key]*firingAlert)
+	pending []notification
+
+	// key and msg of one-off alerts => last sent, see Send
+	sent = make(map[string]time.Time)
+)
+
+// one-off alerts are deduplicated for this duration if reminderInterval is 0
+const SEND_DEDUP_WINDOW = 1 * time.Hour
+
+func SetRules(wait, reminder time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	groupWait = wait
+	reminderInterval = reminder
+}
+
+// Fire sends an alert only when the condition becomes unhealthy,
+// and reminds it every reminderInterval while it's firing
+func Fire(key Key, severity Severity, msg string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	now := time.Now().UTC()
+
+	silenced := isSilenced(key, now)
+
+	alert, ok := firing[key]
+	if !ok {
+		firing[key] = &firingAlert{
+			severity: severity,
+			msg:      msg,
+
+			firedAt:  now,
+			lastSent: now,
+			notified: !silenced,
+		}
+		if !silenced {
+			notify(key, severity, msg, false)
+		}
+
+		return
+	}
+
+	alert.severity = severity
+	alert.msg = msg
+
+	if silenced {
+		return
+	}
+
+	// the silence is over while firing
+	if !alert.notified {
+		alert.notified = true
+		alert.lastSent = now
+		notify(key, severity, msg, false)
+
+		return
+	}
+
+	if reminderInterval == 0 || now.Sub(alert.lastSent) < reminderInterval {
+		return
+	}
+	alert.lastSent = now
+
+	reminder := fmt.Sprintf("[reminder] firing since %s\n%s", alert.firedAt.Format(time.RFC3339), msg)
+	notify(key, severity, reminder, false)
+}
+
+// Resolve sends a resolved notification if the condition was firing
+func Resolve(key Key, msg string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	alert, ok := firing[key]
+	if !ok {
+		return
+	}
+	delete(firing, key)
+
+	// silences suppress triggers and reminders only, what was triggered should be resolved
+	if !alert.notified {
+		return
+	}
+
+	// same severity with the firing alert, so every sink received it gets resolved
+	resolved := fmt.Sprintf("[resolved] after %s\n%s", time.Since(alert.firedAt).Round(time.Second), msg)
+	notify(key, alert.severity, resolved, true)
+}
+
+// the caller should hold the lock
+func notify(key Key, severity Severity, msg string, resolved bool) {
+	enqueueNotification(notification{key: key, severity: severity, msg: msg, resolved: resolved})
+}
+
+// the caller should hold the lock
+func enqueueNotification(notification notification) {
+	if len(alerters) == 0 {
+		return
+	}
+
+	pending = append(pending, notification)
+	if len(pending) == 1 {
+		time.AfterFunc(groupWait, flush)
+	}
+}
+
+// send pending notifications as one message per alerter
+func flush() {
+	mutex.Lock()
+	notifications := pending
+	pending = nil
+	mutex.Unlock()
+
+	for _, alerter := range alerters {
+		// incidents are triggered and resolved one by one with their keys,
+		// one-off alerts are not sent since they would never be resolved
+		if eventAlerter, ok := alerter.(EventAlerter); ok {
+			for _, notification := range notifications {
+				if notification.severity < alerter.MinSeverity() || notification.oneOff {
+					continue
+				}
+				enqueueEvent(eventAlerter, notification.event())
+			}
+			continue
+		}
+
+		severity := INFO
+		msgs := make([]string, 0, len(notifications))
+		for _, notification := range notifications {
+			if notification.severity < alerter.MinSeverity() {
+				continue
+			}
+
+			msgs = append(msgs, notification.msg)
+			if notification.severity > severity {
+				severity = notification.severity
+			}
+		}
+
+		if len(msgs) == 0 {
+			continue
+		}
+
+		msg := strings.Join(msgs, "\n\n")
+		if len(msgs) > 1 {
+			msg = fmt.Sprintf("%d alerts\n\n%s", len(msgs), msg)
+		}
+
+		enqueue(alerter, severity, msg)
+	}
+}
+
+func (notification notification) event() Event {
+	return Event{
+		DedupKey: notification.key.dedupKey(),
+		Severity: notification.severity,
+		Msg:      notification.msg,
+		Resolved: notification.resolved,
+	}
+}
+
+// same for the notifications of a condition, from firing to resolved
+func (key Key) dedupKey() string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key.String())))
+}
@@ -0,0 +1,218 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// alerter which records the messages sent to it
+type testAlerter struct {
+	minSeverity Severity
+
+	severities []Severity
+	msgs       []string
+}
+
+func (alerter *testAlerter) Name() string {
+	return "test"
+}
+
+func (alerter *testAlerter) MinSeverity() Severity {
+	return alerter.minSeverity
+}
+
+func (alerter *testAlerter) Send(title string, severity Severity, msg string) error {
+	alerter.severities = append(alerter.severities, severity)
+	alerter.msgs = append(alerter.msgs, msg)
+	return nil
+}
+
+// event alerter which records the events sent to it
+type testEventAlerter struct {
+	testAlerter
+
+	events []Event
+}
+
+func (alerter *testEventAlerter) SendEvent(title string, event Event) error {
+	alerter.events = append(alerter.events, event)
+	return nil
+}
+
+// reset the alerts with the alerters, notifications are sent only by deliver
+func setupAlerts(t *testing.T, a ...Alerter) {
+	t.Helper()
+
+	mutex.Lock()
+	groupWait = 1 * time.Hour
+	reminderInterval = 0
+	firing = make(map[Key]*firingAlert)
+	pending = nil
+	sent = make(map[string]time.Time)
+	mutex.Unlock()
+
+	silencesMutex.Lock()
+	silences = make(map[string]Silence)
+	silenceStore = nil
+	silencesMutex.Unlock()
+
+	title = "ibcmon"
+	alerters = a
+	queue = make(chan func(), 1024)
+}
+
+// flush the pending notifications and send them
+func deliver() {
+	flush()
+	for {
+		select {
+		case send := <-queue:
+			send()
+		default:
+			return
+		}
+	}
+}
+
+var (
+	channelKey = Key{ChainId: "src-1", CounterpartyChainId: "dst-1", ChannelId: "channel-0", PortId: "transfer", Condition: "channel_state"}
+	clientKey  = Key{ChainId: "src-1", CounterpartyChainId: "dst-1", ClientId: "07-tendermint-0", Condition: "client_status"}
+)
+
+func TestAlertManager(t *testing.T) {
+	tests := []struct {
+		name string
+
+		reminderInterval time.Duration
+		minSeverity      Severity
+		run              func()
+
+		// messages sent to the alerter, each of them starts with the expected one
+		expected   []string
+		severities []Severity
+	}{
+		{
+			name: "fired once while firing",
+			run: func() {
+				Fire(channelKey, WARNING, "channel is closed")
+				Fire(channelKey, WARNING, "channel is closed")
+			},
+			expected:   []string{"channel is closed"},
+			severities: []Severity{WARNING},
+		},
+		{
+			name: "resolved once",
+			run: func() {
+				Fire(channelKey, WARNING, "channel is closed")
+				deliver()
+				Resolve(channelKey, "channel is open")
+				Resolve(channelKey, "channel is open")
+			},
+			expected:   []string{"channel is closed", "[resolved] after"},
+			severities: []Severity{WARNING, WARNING},
+		},
+		{
+			name: "not firing is not resolved",
+			run: func() {
+				Resolve(channelKey, "channel is open")
+			},
+			expected: nil,
+		},
+		{
+			name:             "reminded after reminder interval",
+			reminderInterval: 1 * time.Millisecond,
+			run: func() {
+				Fire(channelKey, WARNING, "channel is closed")
+				deliver()
+				time.Sleep(2 * time.Millisecond)
+				Fire(channelKey, WARNING, "channel is still closed")
+			},
+			expected:   []string{"channel is closed", "[reminder] firing since"},
+			severities: []Severity{WARNING, WARNING},
+		},
+		{
+			name: "grouped within group wait",
+			run: func() {
+				Fire(channelKey, WARNING, "channel is closed")
+				Fire(clientKey, CRITICAL, "client is frozen")
+			},
+			expected:   []string{"2 alerts\n\nchannel is closed\n\nclient is frozen"},
+			severities: []Severity{CRITICAL},
+		},
+		{
+			name:        "lower severity is filtered",
+			minSeverity: ERROR,
+			run: func() {
+				Fire(channelKey, WARNING, "channel is closed")
+				Fire(clientKey, CRITICAL, "client is frozen")
+			},
+			expected:   []string{"client is frozen"},
+			severities: []Severity{CRITICAL},
+		},
+		{
+			name: "same one-off alert is sent once",
+			run: func() {
+				Send(channelKey, ERROR, "failed to query")
+				Send(channelKey, ERROR, "failed to query")
+				Send(channelKey, ERROR, "failed to search")
+			},
+			expected:   []string{"2 alerts\n\nfailed to query\n\nfailed to search"},
+			severities: []Severity{ERROR},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alerter := &testAlerter{minSeverity: test.minSeverity}
+			setupAlerts(t, alerter)
+			reminderInterval = test.reminderInterval
+
+			test.run()
+			deliver()
+
+			if len(alerter.msgs) != len(test.expected) {
+				t.Fatalf("expected %d messages, got %d: %q", len(test.expected), len(alerter.msgs), alerter.msgs)
+			}
+			for i, expected := range test.expected {
+				if !strings.HasPrefix(alerter.msgs[i], expected) {
+					t.Fatalf("expected %q, got %q", expected, alerter.msgs[i])
+				}
+				if alerter.severities[i] != test.severities[i] {
+					t.Fatalf("expected %s, got %s", test.severities[i], alerter.severities[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEventAlerter(t *testing.T) {
+	alerter := &testEventAlerter{}
+	setupAlerts(t, alerter)
+
+	Fire(channelKey, WARNING, "channel is closed")
+	Fire(clientKey, CRITICAL, "client is frozen")
+	// one-off alerts would never be resolved
+	Send(channelKey, ERROR, "failed to query")
+	deliver()
+	Resolve(channelKey, "channel is open")
+	deliver()
+
+	expected := []Event{
+		{DedupKey: channelKey.dedupKey(), Severity: WARNING, Resolved: false},
+		{DedupKey: clientKey.dedupKey(), Severity: CRITICAL, Resolved: false},
+		{DedupKey: channelKey.dedupKey(), Severity: WARNING, Resolved: true},
+	}
+	if len(alerter.events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(alerter.events), alerter.events)
+	}
+	for i, event := range alerter.events {
+		event.Msg = ""
+		if event != expected[i] {
+			t.Fatalf("expected %+v, got %+v", expected[i], event)
+		}
+	}
+	if len(alerter.msgs) != 0 {
+		t.Fatalf("events should not be grouped, got %q", alerter.msgs)
+	}
+}
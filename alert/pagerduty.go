@@ -0,0 +1,66 @@
+package alert
+
+const PAGERDUTY_EVENTS_URL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty triggers and resolves incidents with Events API v2
+type PagerDuty struct {
+	routingKey  string
+	minSeverity Severity
+}
+type PagerDutyBody struct {
+	RoutingKey  string `json:"routing_key"`
+	EventAction string `json:"event_action"`
+	// incidents with the same dedup key are grouped, and resolved by it
+	DedupKey string            `json:"dedup_key,omitempty"`
+	Payload  *PagerDutyPayload `json:"payload,omitempty"`
+}
+type PagerDutyPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+func NewPagerDuty(routingKey string, minSeverity Severity) *PagerDuty {
+	return &PagerDuty{
+		routingKey:  routingKey,
+		minSeverity: minSeverity,
+	}
+}
+
+func (pagerDuty *PagerDuty) Name() string {
+	return "pagerduty"
+}
+
+func (pagerDuty *PagerDuty) MinSeverity() Severity {
+	return pagerDuty.minSeverity
+}
+
+func (pagerDuty *PagerDuty) Send(title string, severity Severity, msg string) error {
+	return pagerDuty.SendEvent(title, Event{Severity: severity, Msg: msg})
+}
+
+func (pagerDuty *PagerDuty) SendEvent(title string, event Event) error {
+	// payload is not required to resolve
+	if event.Resolved {
+		body := PagerDutyBody{
+			RoutingKey:  pagerDuty.routingKey,
+			EventAction: "resolve",
+			DedupKey:    event.DedupKey,
+		}
+		return postJSON(PAGERDUTY_EVENTS_URL, body)
+	}
+
+	body := PagerDutyBody{
+		RoutingKey:  pagerDuty.routingKey,
+		EventAction: "trigger",
+		DedupKey:    event.DedupKey,
+		Payload: &PagerDutyPayload{
+			// pagerduty rejects summaries longer than 1024 characters
+			Summary:  truncate(event.Msg, 1024),
+			Source:   title,
+			Severity: event.Severity.String(),
+		},
+	}
+
+	return postJSON(PAGERDUTY_EVENTS_URL, body)
+}
@@ -0,0 +1,231 @@
+package alert
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/state"
+	"github.com/pkg/errors"
+)
+
+const (
+	SILENCES_STATE_KEY = "silences"
+	// prefix of the ids of silences in config file
+	CONFIG_SILENCE_PREFIX = "config-"
+)
+
+// Silence mutes alerts matched with every non-empty field during [StartsAt, EndsAt)
+type Silence struct {
+	Id string `json:"id" toml:"-"`
+
+	// matched with both of the chain and its counterparty
+	ChainId   string `json:"chain_id" toml:"chain_id"`
+	ClientId  string `json:"client_id" toml:"client_id"`
+	ChannelId string `json:"channel_id" toml:"channel_id"`
+	AlertType string `json:"alert_type" toml:"alert_type"`
+
+	StartsAt time.Time `json:"starts_at" toml:"starts_at"`
+	EndsAt   time.Time `json:"ends_at" toml:"ends_at"`
+
+	Comment string `json:"comment" toml:"comment"`
+}
+
+var (
+	silencesMutex sync.RWMutex
+
+	// id => Silence
+	silences     = make(map[string]Silence)
+	silenceStore state.StateStore
+)
+
+func (silence Silence) validate() error {
+	if silence.ChainId == "" && silence.ClientId == "" && silence.ChannelId == "" && silence.AlertType == "" {
+		return errors.New("silence should match at least one of chain_id, client_id, channel_id or alert_type")
+	}
+	if silence.EndsAt.IsZero() {
+		return errors.New("silence should have ends_at")
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return errors.New("ends_at of silence should be after starts_at")
+	}
+
+	return nil
+}
+
+func (silence Silence) matches(key Key, now time.Time) bool {
+	if now.Before(silence.StartsAt) || !now.Before(silence.EndsAt) {
+		return false
+	}
+
+	if silence.ChainId != "" && silence.ChainId != key.ChainId && silence.ChainId != key.CounterpartyChainId {
+		return false
+	}
+	if silence.ClientId != "" && silence.ClientId != key.ClientId {
+		return false
+	}
+	if silence.ChannelId != "" && silence.ChannelId != key.ChannelId {
+		return false
+	}
+	if silence.AlertType != "" && silence.AlertType != key.Condition {
+		return false
+	}
+
+	return true
+}
+
+// SetSilences restores silences from the store and adds silences in config file
+func SetSilences(store state.StateStore, configured []Silence) error {
+	silencesMutex.Lock()
+	defer silencesMutex.Unlock()
+
+	silenceStore = store
+
+	var persisted []Silence
+	_, err := store.Get(SILENCES_STATE_KEY, &persisted)
+	if err != nil {
+		return err
+	}
+	for _, silence := range persisted {
+		// silences removed from config file are dropped
+		if strings.HasPrefix(silence.Id, CONFIG_SILENCE_PREFIX) {
+			continue
+		}
+		silences[silence.Id] = silence
+	}
+
+	prev := make(map[string]Silence)
+	for _, silence := range persisted {
+		prev[silence.Id] = silence
+	}
+
+	for i, silence := range configured {
+		silence.Id = silence.configId()
+		if silence.StartsAt.IsZero() {
+			// keep when the silence started first, not every restart
+			silence.StartsAt = time.Now().UTC()
+			if prevSilence, ok := prev[silence.Id]; ok {
+				silence.StartsAt = prevSilence.StartsAt
+			}
+		}
+
+		err := silence.validate()
+		if err != nil {
+			return errors.Wrapf(err, "invalid silence in config file: %d", i)
+		}
+
+		silences[silence.Id] = silence
+	}
+
+	return saveSilences()
+}
+
+// id of the silence in config file derived from its content, so it's the same across restarts and reordering
+func (silence Silence) configId() string {
+	content := fmt.Sprintf(
+		"%s/%s/%s/%s/%d/%d",
+		silence.ChainId, silence.ClientId, silence.ChannelId, silence.AlertType,
+		silence.StartsAt.UnixNano(), silence.EndsAt.UnixNano(),
+	)
+	hash := sha256.Sum256([]byte(content))
+
+	return CONFIG_SILENCE_PREFIX + hex.EncodeToString(hash[:8])
+}
+
+func AddSilence(silence Silence) (Silence, error) {
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now().UTC()
+	}
+
+	err := silence.validate()
+	if err != nil {
+		return Silence{}, err
+	}
+
+	id := make([]byte, 8)
+	_, err = rand.Read(id)
+	if err != nil {
+		return Silence{}, errors.Wrap(err, "failed to generate silence id")
+	}
+	silence.Id = hex.EncodeToString(id)
+
+	silencesMutex.Lock()
+	defer silencesMutex.Unlock()
+
+	silences[silence.Id] = silence
+
+	return silence, saveSilences()
+}
+
+// return false if there's no such silence
+func DeleteSilence(id string) (bool, error) {
+	silencesMutex.Lock()
+	defer silencesMutex.Unlock()
+
+	_, ok := silences[id]
+	if !ok {
+		return false, nil
+	}
+	delete(silences, id)
+
+	return true, saveSilences()
+}
+
+// Silences returns active and pending silences sorted by starts_at
+func Silences() []Silence {
+	silencesMutex.RLock()
+	defer silencesMutex.RUnlock()
+
+	now := time.Now().UTC()
+
+	result := make([]Silence, 0, len(silences))
+	for _, silence := range silences {
+		if !now.Before(silence.EndsAt) {
+			continue
+		}
+		result = append(result, silence)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartsAt.Before(result[j].StartsAt)
+	})
+
+	return result
+}
+
+func isSilenced(key Key, now time.Time) bool {
+	silencesMutex.RLock()
+	defer silencesMutex.RUnlock()
+
+	for _, silence := range silences {
+		if silence.matches(key, now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expired silences are dropped, the caller should hold the lock
+func saveSilences() error {
+	now := time.Now().UTC()
+
+	persisted := make([]Silence, 0, len(silences))
+	for id, silence := range silences {
+		if !now.Before(silence.EndsAt) {
+			delete(silences, id)
+			continue
+		}
+		persisted = append(persisted, silence)
+	}
+
+	if silenceStore == nil {
+		return nil
+	}
+
+	return silenceStore.Put(SILENCES_STATE_KEY, persisted)
+}
@@ -0,0 +1,280 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/state"
+)
+
+func TestSilenceMatches(t *testing.T) {
+	now := time.Now().UTC()
+	startsAt, endsAt := now.Add(-1*time.Hour), now.Add(1*time.Hour)
+
+	tests := []struct {
+		name string
+
+		silence Silence
+		key     Key
+
+		expected bool
+	}{
+		{
+			name:     "chain",
+			silence:  Silence{ChainId: "src-1", StartsAt: startsAt, EndsAt: endsAt},
+			key:      channelKey,
+			expected: true,
+		},
+		{
+			name:     "counterparty chain",
+			silence:  Silence{ChainId: "dst-1", StartsAt: startsAt, EndsAt: endsAt},
+			key:      channelKey,
+			expected: true,
+		},
+		{
+			name:     "other chain",
+			silence:  Silence{ChainId: "other-1", StartsAt: startsAt, EndsAt: endsAt},
+			key:      channelKey,
+			expected: false,
+		},
+		{
+			name:     "every field",
+			silence:  Silence{ChainId: "src-1", ChannelId: "channel-0", AlertType: "channel_state", StartsAt: startsAt, EndsAt: endsAt},
+			key:      channelKey,
+			expected: true,
+		},
+		{
+			name:     "one of fields unmatched",
+			silence:  Silence{ChainId: "src-1", ClientId: "07-tendermint-0", StartsAt: startsAt, EndsAt: endsAt},
+			key:      channelKey,
+			expected: false,
+		},
+		{
+			name:     "alert type",
+			silence:  Silence{AlertType: "client_status", StartsAt: startsAt, EndsAt: endsAt},
+			key:      clientKey,
+			expected: true,
+		},
+		{
+			name:     "not started",
+			silence:  Silence{ChainId: "src-1", StartsAt: now.Add(1 * time.Minute), EndsAt: endsAt},
+			key:      channelKey,
+			expected: false,
+		},
+		{
+			name:     "ended",
+			silence:  Silence{ChainId: "src-1", StartsAt: startsAt, EndsAt: now},
+			key:      channelKey,
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.silence.matches(test.key, now)
+			if result != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestSilenceValidate(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name string
+
+		silence Silence
+
+		err bool
+	}{
+		{
+			name:    "valid",
+			silence: Silence{ChainId: "src-1", StartsAt: now, EndsAt: now.Add(1 * time.Hour)},
+		},
+		{
+			name:    "no matcher",
+			silence: Silence{StartsAt: now, EndsAt: now.Add(1 * time.Hour)},
+			err:     true,
+		},
+		{
+			name:    "no ends_at",
+			silence: Silence{ChainId: "src-1", StartsAt: now},
+			err:     true,
+		},
+		{
+			name:    "ends_at before starts_at",
+			silence: Silence{ChainId: "src-1", StartsAt: now, EndsAt: now.Add(-1 * time.Hour)},
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.silence.validate()
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestSilencedAlerts(t *testing.T) {
+	tests := []struct {
+		name string
+
+		run func(t *testing.T)
+
+		expected []string
+	}{
+		{
+			name: "silenced alert is not fired",
+			run: func(t *testing.T) {
+				silence(t, channelKey)
+				Fire(channelKey, WARNING, "channel is closed")
+				Fire(clientKey, CRITICAL, "client is frozen")
+			},
+			expected: []string{"client is frozen"},
+		},
+		{
+			name: "silenced one-off alert is not sent",
+			run: func(t *testing.T) {
+				silence(t, channelKey)
+				Send(channelKey, ERROR, "failed to query")
+			},
+			expected: nil,
+		},
+		{
+			name: "alert silenced while firing is resolved",
+			run: func(t *testing.T) {
+				Fire(channelKey, WARNING, "channel is closed")
+				deliver()
+				silence(t, channelKey)
+				Resolve(channelKey, "channel is open")
+			},
+			expected: []string{"channel is closed", "[resolved] after"},
+		},
+		{
+			name: "alert silenced before notified is not resolved",
+			run: func(t *testing.T) {
+				silence(t, channelKey)
+				Fire(channelKey, WARNING, "channel is closed")
+				deliver()
+				Resolve(channelKey, "channel is open")
+			},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alerter := &testAlerter{}
+			setupAlerts(t, alerter)
+
+			test.run(t)
+			deliver()
+
+			if len(alerter.msgs) != len(test.expected) {
+				t.Fatalf("expected %d messages, got %d: %q", len(test.expected), len(alerter.msgs), alerter.msgs)
+			}
+			for i, expected := range test.expected {
+				if !strings.HasPrefix(alerter.msgs[i], expected) {
+					t.Fatalf("expected %q, got %q", expected, alerter.msgs[i])
+				}
+			}
+		})
+	}
+}
+
+// silence the channel of the key for an hour
+func silence(t *testing.T, key Key) {
+	t.Helper()
+
+	_, err := AddSilence(Silence{ChannelId: key.ChannelId, EndsAt: time.Now().UTC().Add(1 * time.Hour)})
+	if err != nil {
+		t.Fatalf("failed to add silence: %v", err)
+	}
+}
+
+func TestSetSilences(t *testing.T) {
+	setupAlerts(t)
+	store := state.NewMemoryStore()
+	endsAt := time.Now().UTC().Add(1 * time.Hour)
+
+	configured := []Silence{{ChainId: "src-1", EndsAt: endsAt}}
+	err := SetSilences(store, configured)
+	if err != nil {
+		t.Fatalf("failed to set silences: %v", err)
+	}
+	added, err := AddSilence(Silence{ChainId: "dst-1", EndsAt: endsAt})
+	if err != nil {
+		t.Fatalf("failed to add silence: %v", err)
+	}
+
+	first := Silences()
+	if len(first) != 2 {
+		t.Fatalf("expected 2 silences, got %+v", first)
+	}
+	var configSilence Silence
+	for _, silence := range first {
+		if strings.HasPrefix(silence.Id, CONFIG_SILENCE_PREFIX) {
+			configSilence = silence
+		}
+	}
+	if configSilence.Id == "" {
+		t.Fatalf("expected a silence from config file, got %+v", first)
+	}
+
+	tests := []struct {
+		name string
+
+		configured []Silence
+
+		// ids of silences after restart
+		expected []string
+	}{
+		{
+			name:       "restarted with the same config",
+			configured: configured,
+			expected:   []string{configSilence.Id, added.Id},
+		},
+		{
+			name:       "silence removed from config",
+			configured: nil,
+			expected:   []string{added.Id},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// restart without the silences in memory
+			silencesMutex.Lock()
+			silences = make(map[string]Silence)
+			silencesMutex.Unlock()
+
+			err := SetSilences(store, test.configured)
+			if err != nil {
+				t.Fatalf("failed to set silences: %v", err)
+			}
+
+			result := make(map[string]Silence)
+			for _, silence := range Silences() {
+				result[silence.Id] = silence
+			}
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %d silences, got %+v", len(test.expected), result)
+			}
+			for _, id := range test.expected {
+				if _, ok := result[id]; !ok {
+					t.Fatalf("expected silence %s, got %+v", id, result)
+				}
+			}
+			// the silence in config file keeps when it started first
+			if silence, ok := result[configSilence.Id]; ok && !silence.StartsAt.Equal(configSilence.StartsAt) {
+				t.Fatalf("expected starts_at %s, got %s", configSilence.StartsAt, silence.StartsAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package alert
+
+import (
+	"fmt"
+)
+
+// Slack sends alerts to an incoming webhook
+type Slack struct {
+	webhookURL  string
+	minSeverity Severity
+}
+type SlackBody struct {
+	Text string `json:"text"`
+}
+
+func NewSlack(webhookURL string, minSeverity Severity) *Slack {
+	return &Slack{
+		webhookURL:  webhookURL,
+		minSeverity: minSeverity,
+	}
+}
+
+func (slack *Slack) Name() string {
+	return "slack"
+}
+
+func (slack *Slack) MinSeverity() Severity {
+	return slack.minSeverity
+}
+
+func (slack *Slack) Send(title string, severity Severity, msg string) error {
+	body := SlackBody{
+		Text: fmt.Sprintf("*%s*\n[%s] %s", title, severity, msg),
+	}
+
+	return postJSON(slack.webhookURL, body)
+}
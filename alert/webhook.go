@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"time"
+)
+
+// Webhook posts alerts as JSON to any endpoint
+type Webhook struct {
+	url         string
+	minSeverity Severity
+}
+type WebhookBody struct {
+	Title     string    `json:"title"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func NewWebhook(url string, minSeverity Severity) *Webhook {
+	return &Webhook{
+		url:         url,
+		minSeverity: minSeverity,
+	}
+}
+
+func (webhook *Webhook) Name() string {
+	return "webhook"
+}
+
+func (webhook *Webhook) MinSeverity() Severity {
+	return webhook.minSeverity
+}
+
+func (webhook *Webhook) Send(title string, severity Severity, msg string) error {
+	body := WebhookBody{
+		Title:     title,
+		Severity:  severity.String(),
+		Message:   msg,
+		Timestamp: time.Now().UTC(),
+	}
+
+	return postJSON(webhook.url, body)
+}
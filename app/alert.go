@@ -0,0 +1,67 @@
+package app
+
+import (
+	"github.com/dlvlabs/ibcmon/alert"
+	"github.com/pkg/errors"
+)
+
+// alert types, used as the condition of alert.Key and matched with silences
+const (
+	ALERT_CLIENT_STATUS          = "client_status"
+	ALERT_CLIENT_EXPIRATION      = "client_expiration"
+	ALERT_CLIENT_EXPIRY_FORECAST = "client_expiry_forecast"
+	ALERT_MISSED_PACKETS         = "missed_packets"
+	ALERT_UNRELAYED_PACKETS      = "unrelayed_packets"
+	ALERT_CHANNEL_STATE          = "channel_state"
+	ALERT_CHANNEL_UPGRADE        = "channel_upgrade"
+	ALERT_UNCONFIRMED_TIMEOUTS   = "unconfirmed_timeouts"
+	ALERT_JOB_FAILURE            = "job_failure"
+	ALERT_TOPOLOGY_CHANGE        = "topology_change"
+)
+
+// Alerters returns every enabled alert sink in config
+func (cfg Config) Alerters() ([]alert.Alerter, error) {
+	var alerters []alert.Alerter
+
+	if cfg.TG.Enable {
+		severity, err := alert.ParseSeverity(cfg.TG.Severity)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid tg config")
+		}
+		alerters = append(alerters, alert.NewTG(cfg.TG.Token, cfg.TG.ChatID, severity))
+	}
+
+	if cfg.Slack.Enable {
+		severity, err := alert.ParseSeverity(cfg.Slack.Severity)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid slack config")
+		}
+		alerters = append(alerters, alert.NewSlack(cfg.Slack.WebhookURL, severity))
+	}
+
+	if cfg.Discord.Enable {
+		severity, err := alert.ParseSeverity(cfg.Discord.Severity)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid discord config")
+		}
+		alerters = append(alerters, alert.NewDiscord(cfg.Discord.WebhookURL, severity))
+	}
+
+	if cfg.PagerDuty.Enable {
+		severity, err := alert.ParseSeverity(cfg.PagerDuty.Severity)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid pagerduty config")
+		}
+		alerters = append(alerters, alert.NewPagerDuty(cfg.PagerDuty.RoutingKey, severity))
+	}
+
+	if cfg.Webhook.Enable {
+		severity, err := alert.ParseSeverity(cfg.Webhook.Severity)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid webhook config")
+		}
+		alerters = append(alerters, alert.NewWebhook(cfg.Webhook.URL, severity))
+	}
+
+	return alerters, nil
+}
@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/dlvlabs/ibcmon/logger"
@@ -10,68 +9,68 @@ import (
 )
 
 func (app *App) Run(ctx context.Context) error {
-	// app.initIBCInfo: run every cfg.General.IbcInfoUpdateInterval.
+	// app.refreshIBCInfo: run every cfg.General.IbcInfoUpdateInterval,
+	// the topology is updated in place and only the trackers of changed channels are started or stopped.
 
 	// app.checkClientsHealth: run every cfg.General.ClientCheckInterval,
 	// app.initIBCInfo should be done before this function.
 
-	// app.trackIBCPacket: this function would be run continuously
+	// app.syncIBCPacketTrackers: start the trackers which run continuously until their channels are closed
 	// app.initIBCInfo should be done before this function.
 
+	// app.checkChannelStates: run every cfg.General.ChannelCheckInterval,
+	// app.initIBCInfo should be done before this function.
+
+	// app.checkUnrelayedPackets: run every cfg.General.UnrelayedCheckInterval if it's set,
+	// app.initIBCInfo should be done before this function.
+
+	// app.runStateSaver: persist app.Store every cfg.State.SaveInterval
+
+	// app.runEndpointChecker: select the fastest endpoint of every chain every cfg.General.EndpointCheckInterval
+
+	// app.runSnapshotPublisher: publish the changes of trackers every SNAPSHOT_INTERVAL
+
+	go app.runStateSaver(ctx)
+	go app.runEndpointChecker(ctx)
+	go app.runSnapshotPublisher(ctx)
+
+	// the first discovery is retried with backoff until it succeeds
+	var backoff time.Duration
 	for {
-		appCtx, cancel := context.WithCancel(ctx)
+		err := app.refreshIBCInfo(ctx)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		backoff = nextBackoff(backoff)
+		logger.Error(errors.Wrapf(err, "init ibc info failed, retry in %s", backoff))
 
-		err := app.initIBCInfo(appCtx)
-		if err != nil {
-			return err
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+	}
+
+	// failed jobs are retried with backoff instead of stopping the monitor
+	go supervise(ctx, "check client health", app.cfg.General.ClientCheckInterval, app.checkClientsHealth, nil)
+
+	go supervise(ctx, "check channel states", app.cfg.General.ChannelCheckInterval, app.checkChannelStates, nil)
 
-		go func() {
-			// Initialize ticker to fire immediately
-			ticker := time.NewTicker(1 * time.Second)
-			defer ticker.Stop()
-
-			msg := fmt.Sprintf("check client health: %s", context.Canceled.Error())
-
-			for {
-				select {
-				case <-ticker.C:
-					err = app.checkClientsHealth(appCtx)
-					if err != nil {
-						if errors.Is(err, context.Canceled) {
-							logger.Info(msg)
-							return
-						}
-
-						logger.Error(err)
-						panic(err)
-					}
-
-					// reset ticket
-					ticker.Reset(app.cfg.General.ClientCheckInterval)
-				case <-appCtx.Done():
-					logger.Info(msg)
-					return
-				}
-			}
-		}()
-
-		go func() {
-			err = app.trackIBCPacket(appCtx)
-			if err != nil {
-				if errors.Is(err, context.Canceled) {
-					msg := fmt.Sprintf("track ibc packet: %s", context.Canceled.Error())
-					logger.Info(msg)
-					return
-				}
-
-				logger.Error(err)
-				panic(err)
-			}
-		}()
-
-		time.Sleep(app.cfg.General.IbcInfoUpdateInterval)
-
-		cancel()
+	if app.cfg.General.UnrelayedCheckInterval != 0 {
+		go supervise(ctx, "check unrelayed packets", app.cfg.General.UnrelayedCheckInterval, app.checkUnrelayedPackets, nil)
 	}
+
+	select {
+	case <-time.After(app.cfg.General.IbcInfoUpdateInterval):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	supervise(ctx, "refresh ibc info", app.cfg.General.IbcInfoUpdateInterval, app.refreshIBCInfo, nil)
+
+	return ctx.Err()
 }
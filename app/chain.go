@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+func (chain Chain) String() string {
+	if chain.V2 {
+		return fmt.Sprintf("%s(%s)", chain.ChainId, chain.ChannelId)
+	}
+	return fmt.Sprintf("%s(%s/%s)", chain.ChainId, chain.ChannelId, chain.PortId)
+}
+
+// packet queries of the channel end, or the client end for IBC v2
+
+func (chain Chain) getNextSequenceSend(ctx context.Context) (uint64, error) {
+	if chain.V2 {
+		return chain.grpc.GetNextSequenceSendV2(ctx, chain.ChannelId)
+	}
+	return chain.grpc.GetNextSequenceSend(ctx, chain.ChannelId, chain.PortId)
+}
+
+func (chain Chain) getPacketCommitments(ctx context.Context) ([]uint64, error) {
+	if chain.V2 {
+		return chain.grpc.GetPacketCommitmentsV2(ctx, chain.ChannelId)
+	}
+	return chain.grpc.GetPacketCommitments(ctx, chain.ChannelId, chain.PortId)
+}
+
+func (chain Chain) getUnreceivedPackets(ctx context.Context, sequences []uint64) ([]uint64, error) {
+	if chain.V2 {
+		return chain.grpc.GetUnreceivedPacketsV2(ctx, chain.ChannelId, sequences)
+	}
+	return chain.grpc.GetUnreceivedPackets(ctx, chain.ChannelId, chain.PortId, sequences)
+}
+
+func (chain Chain) getPacketAcknowledgements(ctx context.Context, sequences []uint64) ([]uint64, error) {
+	if chain.V2 {
+		return chain.grpc.GetPacketAcknowledgementsV2(ctx, chain.ChannelId, sequences)
+	}
+	return chain.grpc.GetPacketAcknowledgements(ctx, chain.ChannelId, chain.PortId, sequences)
+}
+
+func (chain Chain) getUnreceivedAcks(ctx context.Context, sequences []uint64) ([]uint64, error) {
+	if chain.V2 {
+		return chain.grpc.GetUnreceivedAcksV2(ctx, chain.ChannelId, sequences)
+	}
+	return chain.grpc.GetUnreceivedAcks(ctx, chain.ChannelId, chain.PortId, sequences)
+}
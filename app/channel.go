@@ -3,32 +3,50 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/dlvlabs/ibcmon/alert"
 	"github.com/dlvlabs/ibcmon/client/grpc"
 	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 
 	connectionTypes "github.com/cosmos/ibc-go/v10/modules/core/03-connection/types"
-	channelTypes "github.com/cosmos/ibc-go/v10/modules/core/04-channel/types"
 )
 
-// set all of open channels
-func (channels *Channels) setOpenChannels(
+// states of connections and channels without "STATE_" prefix
+const (
+	STATE_INIT          = "INIT"
+	STATE_TRYOPEN       = "TRYOPEN"
+	STATE_OPEN          = "OPEN"
+	STATE_CLOSED        = "CLOSED"
+	STATE_FLUSHING      = "FLUSHING"
+	STATE_FLUSHCOMPLETE = "FLUSHCOMPLETE"
+)
+
+func stateName(state fmt.Stringer) string {
+	return strings.TrimPrefix(state.String(), "STATE_")
+}
+
+// set every channel of the connection with its state
+func (channels *Channels) setChannels(
 	ctx context.Context,
 	grpc *grpc.Client,
 	connectionId string,
 	counterparty *connectionTypes.Counterparty,
 ) error {
-	connectionChnnels, err := grpc.GetConnectionChannels(ctx, connectionId)
+	connectionChannels, err := grpc.GetConnectionChannels(ctx, connectionId)
 	if err != nil {
 		return err
 	}
 
-	for _, channel := range connectionChnnels {
-		if channel.State != channelTypes.OPEN {
-			msg := fmt.Sprintf("skipping channel: %s, only open channels are supported", channel.ChannelId)
+	now := time.Now().UTC()
+	for _, channel := range connectionChannels {
+		state := stateName(channel.State)
+		if state != STATE_OPEN {
+			msg := fmt.Sprintf("channel %s is %s", channel.ChannelId, state)
 			logger.Debug(msg)
-
-			continue
 		}
 
 		(*channels)[channel.ChannelId] = &Channel{
@@ -40,7 +58,148 @@ func (channels *Channels) setOpenChannels(
 				PortId:       channel.Counterparty.PortId,
 			},
 		}
+		(*channels)[channel.ChannelId].setState(state, now)
 	}
 
 	return nil
 }
+
+// GetState returns OPEN for IBC v2 paths and the channels restored from the state saved before states are recorded
+func (channel *Channel) GetState() string {
+	if channel.State == "" {
+		return STATE_OPEN
+	}
+	return channel.State
+}
+
+// packets are relayed through open channels, and the in-flight packets of upgrading channels are flushed
+func (channel *Channel) canRelay() bool {
+	return channel.GetState() == STATE_OPEN || channel.isUpgrading()
+}
+
+func (channel *Channel) isUpgrading() bool {
+	return channel.State == STATE_FLUSHING || channel.State == STATE_FLUSHCOMPLETE
+}
+
+// set the state and when the channel started flushing
+func (channel *Channel) setState(state string, now time.Time) {
+	wasUpgrading := channel.isUpgrading()
+	channel.State = state
+
+	if !channel.isUpgrading() {
+		channel.FlushingSince = time.Time{}
+	} else if !wasUpgrading || channel.FlushingSince.IsZero() {
+		channel.FlushingSince = now
+	}
+}
+
+// max number of channels checked at once by app.checkChannelStates
+const MAX_CHANNEL_STATE_REQUESTS = 16
+
+// check the states of channels between the ibc info updates, and alert if a channel upgrade stalls in flushing
+func (app *App) checkChannelStates(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	defer cancel()
+
+	err := app.connectGRPCs()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = app.terminateGRPCs()
+		if err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	var g errgroup.Group
+	g.SetLimit(MAX_CHANNEL_STATE_REQUESTS)
+
+	for chainId, clients := range app.ibcInfo() {
+		for clientId, client := range clients {
+			for _, path := range client.Paths(clientId) {
+				connectionId, channelId, channel := path.ConnectionId, path.ChannelId, path.Channel
+				if channel.V2 {
+					continue
+				}
+
+				// closed channels can't be opened again
+				app.storeMutex.Lock()
+				closed := channel.GetState() == STATE_CLOSED
+				app.storeMutex.Unlock()
+				if closed {
+					continue
+				}
+
+				g.Go(func() error {
+					// failure of a channel doesn't stop checking the others
+					resp, err := app.getGRPC(chainId).GetChannel(ctx, channelId, channel.PortId)
+					if err != nil {
+						logger.Error(errors.Wrapf(err, "failed to check state of channel %s on %s", channelId, chainId))
+						return nil
+					}
+					state := stateName(resp.State)
+
+					var changes []TopologyChange
+					var flushingSince time.Time
+					app.updateStore(func() {
+						now := time.Now().UTC()
+						changes = channelStateChanges(TopologyChange{
+							Time: now,
+
+							ChainId:             chainId,
+							CounterpartyChainId: client.ChainId,
+							ClientId:            clientId,
+							ConnectionId:        connectionId,
+							ChannelId:           channelId,
+							PortId:              channel.PortId,
+						}, channel.GetState(), state)
+
+						channel.setState(state, now)
+						flushingSince = channel.FlushingSince
+
+						app.Store.record(changes)
+					})
+					notifyChanges(changes)
+
+					alertKey := alert.Key{
+						ChainId:             chainId,
+						CounterpartyChainId: client.ChainId,
+						ClientId:            clientId,
+						ChannelId:           channelId,
+						PortId:              channel.PortId,
+
+						Condition: ALERT_CHANNEL_UPGRADE,
+					}
+					if !flushingSince.IsZero() && time.Since(flushingSince) >= app.cfg.Rule.ChannelFlushingWarningTime {
+						msg := fmt.Sprintf(
+							"channel upgrade stalls in %s for %s: %s(%s/%s/%s/%s) => %s",
+							state, time.Since(flushingSince).Round(time.Second),
+							chainId, clientId, connectionId, channelId, channel.PortId, client.ChainId,
+						)
+						logger.Warn(msg)
+						alert.Fire(alertKey, alert.WARNING, msg)
+					} else {
+						msg := fmt.Sprintf(
+							"channel is %s: %s(%s/%s/%s/%s) => %s",
+							state, chainId, clientId, connectionId, channelId, channel.PortId, client.ChainId,
+						)
+						alert.Resolve(alertKey, msg)
+					}
+
+					return nil
+				})
+			}
+		}
+	}
+
+	return g.Wait()
+}
+
+// whether packets can be relayed through the channel, app.storeMutex is held for the state updated by app.checkChannelStates
+func (app *App) canRelay(channel *Channel) bool {
+	app.storeMutex.Lock()
+	defer app.storeMutex.Unlock()
+
+	return channel.canRelay()
+}
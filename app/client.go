@@ -6,125 +6,147 @@ import (
 
 	"github.com/dlvlabs/ibcmon/client/grpc"
 	"github.com/dlvlabs/ibcmon/logger"
-	"github.com/pkg/errors"
 
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/ibc-go/v10/modules/core/exported"
-	tendermint "github.com/cosmos/ibc-go/v10/modules/light-clients/07-tendermint"
 )
 
-// set all of active and tendermint clients
-func (clients *Clients) setActiveClients(ctx context.Context, grpc *grpc.Client, cdc codectypes.InterfaceRegistry) error {
+// set all of active clients,
+// the clients in prev are kept with their status even if they are not active anymore
+func (clients *Clients) setClients(ctx context.Context, grpc *grpc.Client, cdc codectypes.InterfaceRegistry, prev Clients) error {
 	clientStates, err := grpc.GetClientStates(ctx)
 	if err != nil {
 		return err
 	}
 
 	for _, cs := range clientStates {
-		// only support tendermint light clients
-		if cs.ClientState.TypeUrl != "/ibc.lightclients.tendermint.v1.ClientState" {
-			msg := fmt.Sprintf("skipping client: %s, only tendermint light clients are supported", cs.ClientId)
-			logger.Debug(msg)
-
-			continue
-		}
-
-		status, err := grpc.GetClientStatus(ctx, cs.ClientId)
+		err := clients.setClient(ctx, grpc, cdc, cs.ClientId, cs.ClientState, prev)
 		if err != nil {
 			return err
 		}
+	}
 
-		if !status {
-			msg := fmt.Sprintf("client %s is not active", cs.ClientId)
-			logger.Debug(msg)
-
-			continue
-		}
-
-		var iClientState exported.ClientState
-		if err := cdc.UnpackAny(cs.ClientState, &iClientState); err != nil {
-			return errors.Wrapf(err, "failed to unpack client state for client: %s", cs.ClientId)
-		}
-		clientState, ok := iClientState.(*tendermint.ClientState)
-		if !ok {
-			return errors.Wrapf(err, "invalid client state type: %T", iClientState)
-		}
-
-		connections := make(Connections)
-		err = connections.setOpenConnections(ctx, grpc, cs.ClientId)
+	// localhost client has no client state since ibc-go v9, so it's not listed in client states
+	if _, ok := (*clients)[exported.LocalhostClientID]; !ok {
+		err = clients.setClient(ctx, grpc, cdc, exported.LocalhostClientID, &codectypes.Any{}, prev)
 		if err != nil {
-			return err
-		}
-
-		(*clients)[cs.ClientId] = &Client{
-			Health: true,
-
-			ChainId:        clientState.ChainId,
-			RevisionNumber: clientState.LatestHeight.RevisionNumber,
-			RevisionHeight: clientState.LatestHeight.RevisionHeight,
-			TrustingPeriod: clientState.TrustingPeriod,
-
-			Connections: connections,
+			msg := fmt.Sprintf("skipping client: %s, %s", exported.LocalhostClientID, err)
+			logger.Debug(msg)
 		}
 	}
 
 	return nil
 }
 
-// set active and tendermint client
-func (clients *Clients) setActiveClient(
+// set active client,
+// the client in prev is kept with its status even if it is not active anymore
+func (clients *Clients) setClient(
 	ctx context.Context,
 	grpc *grpc.Client,
 	cdc codectypes.InterfaceRegistry,
 	clientId string,
+	clientState *codectypes.Any,
+	prev Clients,
 ) error {
-	clientState, err := grpc.GetClientState(ctx, clientId)
+	status, err := grpc.GetClientStatus(ctx, clientId)
 	if err != nil {
 		return err
 	}
 
-	if clientState.TypeUrl != "/ibc.lightclients.tendermint.v1.ClientState" {
-		msg := fmt.Sprintf("skipping client: %s, only tendermint light clients are supported", clientId)
+	_, tracked := prev[clientId]
+	if status != exported.Active.String() && !tracked {
+		msg := fmt.Sprintf("client %s is not active: %s", clientId, status)
 		logger.Debug(msg)
+
+		return nil
 	}
 
-	status, err := grpc.GetClientStatus(ctx, clientId)
+	lightClient, err := newLightClient(cdc, clientId, clientState)
 	if err != nil {
 		return err
 	}
 
-	if !status {
-		msg := fmt.Sprintf("client %s is not active", clientId)
-		logger.Debug(msg)
-
-		return nil
+	client, err := newClient(ctx, grpc, clientId, lightClient, status)
+	if err != nil {
+		return err
 	}
 
-	var iClientState exported.ClientState
-	if err := cdc.UnpackAny(clientState, &iClientState); err != nil {
-		return errors.Wrapf(err, "failed to unpack client state for client: %s", clientId)
-	}
-	cs, ok := iClientState.(*tendermint.ClientState)
-	if !ok {
-		return errors.Wrapf(err, "invalid client state type: %T", iClientState)
-	}
+	(*clients)[clientId] = client
 
-	connections := make(Connections)
-	err = connections.setOpenConnections(ctx, grpc, clientId)
+	return nil
+}
+
+// set the client of counterparty
+func (clients *Clients) setCounterpartyClient(
+	ctx context.Context,
+	grpc *grpc.Client,
+	cdc codectypes.InterfaceRegistry,
+	clientId string,
+	prev Clients,
+) error {
+	clientState, err := grpc.GetClientState(ctx, clientId)
 	if err != nil {
 		return err
 	}
 
-	(*clients)[clientId] = &Client{
-		Health: true,
+	return clients.setClient(ctx, grpc, cdc, clientId, clientState, prev)
+}
+
+// connections of non-active clients are not discovered, because packets can't be relayed through them
+func newClient(ctx context.Context, grpc *grpc.Client, clientId string, lightClient LightClient, status string) (*Client, error) {
+	connections := make(Connections)
+	var connectionStates map[string]string
+	var v2 *Channel
+	if status == exported.Active.String() {
+		var err error
+		connectionStates, err = connections.setConnections(ctx, grpc, clientId)
+		if err != nil {
+			return nil, err
+		}
+
+		v2, err = newV2Channel(ctx, grpc, clientId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	revisionNumber, revisionHeight := lightClient.LatestHeight()
+
+	return &Client{
+		Health:     status == exported.Active.String(),
+		Status:     status,
+		ClientType: lightClient.ClientType(),
 
-		ChainId:        cs.ChainId,
-		RevisionNumber: cs.LatestHeight.RevisionNumber,
-		RevisionHeight: cs.LatestHeight.RevisionHeight,
-		TrustingPeriod: cs.TrustingPeriod,
+		ChainId:        lightClient.ChainId(),
+		RevisionNumber: revisionNumber,
+		RevisionHeight: revisionHeight,
+		TrustingPeriod: lightClient.TrustingPeriod(),
+
+		Connections:      connections,
+		ConnectionStates: connectionStates,
+		V2:               v2,
+	}, nil
+}
 
-		Connections: connections,
+// IBC v2 path of the client, nil if the counterparty client is not registered
+func newV2Channel(ctx context.Context, grpc *grpc.Client, clientId string) (*Channel, error) {
+	counterpartyClientId, err := grpc.GetCounterpartyInfo(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+	if counterpartyClientId == "" {
+		return nil, nil
 	}
 
-	return nil
+	msg := fmt.Sprintf("found IBC v2 counterparty of client %s: %s", clientId, counterpartyClientId)
+	logger.Debug(msg)
+
+	return &Channel{
+		V2:    true,
+		State: STATE_OPEN,
+		Counterparty: &Counterparty{
+			ClientId:  counterpartyClientId,
+			ChannelId: counterpartyClientId,
+		},
+	}, nil
 }
@@ -13,7 +13,6 @@ import (
 
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/ibc-go/v10/modules/core/exported"
-	tendermint "github.com/cosmos/ibc-go/v10/modules/light-clients/07-tendermint"
 )
 
 func (app *App) checkClientsHealth(ctx context.Context) error {
@@ -31,19 +30,31 @@ func (app *App) checkClientsHealth(ctx context.Context) error {
 		}
 	}()
 
-	g, ctx := errgroup.WithContext(ctx)
+	var g errgroup.Group
 
-	for chainId, clients := range app.Store.IBCInfo {
+	// failure of a client is kept in the client, so it doesn't stop checking the others
+	for chainId, clients := range app.ibcInfo() {
 		for clientId, client := range clients {
 			g.Go(func() error {
-				err := client.checkHealth(
-					ctx, app.grpcs[chainId], app.cdc, clientId,
+				// the client is checked on a copy, and the result is applied to the store at once
+				app.storeMutex.Lock()
+				checked := *client
+				app.storeMutex.Unlock()
+
+				err := checked.checkHealth(
+					ctx, app.getGRPC(chainId), app.cdc, chainId, clientId,
 					app.cfg.Rule.ClientExpiredWarningTime,
 				)
 				if err != nil {
+					err = errors.Wrapf(err, "failed to check client %s on %s", clientId, chainId)
 					logger.Error(err)
-					return err
 				}
+				app.updateStore(func() {
+					if err == nil {
+						client.applyHealth(&checked)
+					}
+					client.Degraded = client.Degraded.update(err)
+				})
 
 				return nil
 			})
@@ -57,61 +68,82 @@ func (client *Client) checkHealth(
 	ctx context.Context,
 	grpc *grpc.Client,
 	cdc codectypes.InterfaceRegistry,
-	clientId string,
+	chainId, clientId string,
 	warningTime time.Duration,
 ) error {
-	// Get client state for new RevisionNumber and RevisionHeight
-	state, err := grpc.GetClientState(ctx, clientId)
+	status, err := grpc.GetClientStatus(ctx, clientId)
 	if err != nil {
 		return err
 	}
 
-	if state.TypeUrl != "/ibc.lightclients.tendermint.v1.ClientState" {
-		msg := fmt.Sprintf("skipping client: %s, only tendermint light clients are supported", clientId)
-		logger.Debug(msg)
+	if !client.checkStatus(chainId, clientId, status) {
+		return nil
 	}
 
-	var iClientState exported.ClientState
-	if err := cdc.UnpackAny(state, &iClientState); err != nil {
-		return errors.Wrapf(err, "failed to unpack client state for client: %s", clientId)
+	// Get client state for new RevisionNumber and RevisionHeight,
+	// localhost client has no client state
+	state := &codectypes.Any{}
+	if clientId != exported.LocalhostClientID {
+		state, err = grpc.GetClientState(ctx, clientId)
+		if err != nil {
+			return err
+		}
 	}
-	clientState, ok := iClientState.(*tendermint.ClientState)
-	if !ok {
-		return errors.Wrapf(err, "invalid client state type: %T", iClientState)
+
+	lightClient, err := newLightClient(cdc, clientId, state)
+	if err != nil {
+		return err
 	}
 
 	// Get consensus state for client updated timestamp
-	state, err = grpc.GetConsensusState(ctx, clientId, clientState.LatestHeight.RevisionNumber, clientState.LatestHeight.RevisionHeight)
+	timestamp, err := lightClient.ConsensusTimestamp(ctx, grpc, cdc, clientId)
 	if err != nil {
 		return err
 	}
 
-	var iConsensusState exported.ConsensusState
-	if err := cdc.UnpackAny(state, &iConsensusState); err != nil {
-		return errors.Wrapf(err, "failed to unpack consensus state for client: %s", clientId)
+	// Update client info
+	client.ClientType = lightClient.ClientType()
+	client.RevisionNumber, client.RevisionHeight = lightClient.LatestHeight()
+	client.TrustingPeriod = lightClient.TrustingPeriod()
+
+	// the client type is checked with its status only
+	if timestamp.IsZero() {
+		client.Health = true
+		logger.Info(fmt.Sprintf("client %s(%s) is active", clientId, client.ClientType))
+
+		return nil
 	}
-	consensusState, ok := iConsensusState.(*tendermint.ConsensusState)
-	if !ok {
-		return errors.Wrapf(err, "invalid consensus state type: %T", iConsensusState)
+
+	client.observeUpdate(timestamp)
+	client.ClientUpdated = timestamp
+
+	// the client type doesn't expire by time
+	if client.TrustingPeriod == 0 {
+		client.Health = true
+		logger.Info(fmt.Sprintf("client %s(%s) is healthy", clientId, client.ClientType))
+
+		return nil
 	}
 
-	// Update stored client info
-	// Don't need mutex lock here, because update each client
+	client.forecastExpiry(chainId, clientId)
 
-	client.RevisionNumber = clientState.LatestHeight.RevisionNumber
-	client.RevisionHeight = clientState.LatestHeight.RevisionHeight
-	client.TrustingPeriod = clientState.TrustingPeriod
-	client.ClientUpdated = consensusState.Timestamp
+	alertKey := alert.Key{
+		ChainId:             chainId,
+		CounterpartyChainId: client.ChainId,
+		ClientId:            clientId,
+
+		Condition: ALERT_CLIENT_EXPIRATION,
+	}
 
-	if client.warnExpiration(consensusState.Timestamp, warningTime) {
+	if client.warnExpiration(timestamp, warningTime) {
 		client.Health = false
 
 		msg := fmt.Sprintf(
-			"client %s(%s) would be expired, consensus state timestamp: %s",
-			clientId, client.ChainId, consensusState.Timestamp,
+			"client %s(%s) on %s would be expired in %s, consensus state timestamp: %s",
+			clientId, client.ChainId, chainId, client.TimeToExpiry().Round(time.Second), timestamp,
 		)
 		logger.Warn(msg)
-		alert.SendTg(msg)
+		alert.Fire(alertKey, alert.CRITICAL, msg)
 
 		return nil
 	}
@@ -119,11 +151,148 @@ func (client *Client) checkHealth(
 	client.Health = true
 	logger.Info(fmt.Sprintf("client %s is healthy", clientId))
 
+	msg := fmt.Sprintf("client %s(%s) on %s is updated: %s", clientId, client.ChainId, chainId, timestamp)
+	alert.Resolve(alertKey, msg)
+
 	return nil
 }
 
+// copy the values updated by checkHealth
+func (client *Client) applyHealth(checked *Client) {
+	client.Health = checked.Health
+	client.Status = checked.Status
+	client.CheckedStatus = checked.CheckedStatus
+	client.ClientType = checked.ClientType
+
+	client.RevisionNumber = checked.RevisionNumber
+	client.RevisionHeight = checked.RevisionHeight
+	client.TrustingPeriod = checked.TrustingPeriod
+
+	client.ClientUpdated = checked.ClientUpdated
+	client.UpdateInterval = checked.UpdateInterval
+	client.ExpiryForecast = checked.ExpiryForecast
+}
+
+// checkStatus alerts while the client is not active, returns true if the client is active
+func (client *Client) checkStatus(chainId, clientId, status string) bool {
+	prevStatus := client.CheckedStatus
+	client.Status = status
+	client.CheckedStatus = status
+
+	alertKey := alert.Key{
+		ChainId:             chainId,
+		CounterpartyChainId: client.ChainId,
+		ClientId:            clientId,
+
+		Condition: ALERT_CLIENT_STATUS,
+	}
+
+	if status == exported.Active.String() {
+		if prevStatus != "" && prevStatus != status {
+			msg := fmt.Sprintf("client %s(%s) on %s is active again, previous status: %s", clientId, client.ChainId, chainId, prevStatus)
+			logger.Info(msg)
+		}
+
+		msg := fmt.Sprintf("client %s(%s) on %s is active", clientId, client.ChainId, chainId)
+		alert.Resolve(alertKey, msg)
+
+		return true
+	}
+
+	client.Health = false
+
+	msg := fmt.Sprintf("client %s(%s) on %s is %s", clientId, client.ChainId, chainId, status)
+	if prevStatus != status {
+		msg = fmt.Sprintf("client %s(%s) on %s is changed from %s to %s", clientId, client.ChainId, chainId, prevStatus, status)
+		logger.Warn(msg)
+	}
+	alert.Fire(alertKey, alert.CRITICAL, msg)
+
+	return false
+}
+
+// weight of the latest interval in the moving average of update intervals
+const UPDATE_INTERVAL_SMOOTHING = 0.3
+
+// observeUpdate folds the interval since the previous client update into UpdateInterval
+func (client *Client) observeUpdate(latestTimestamp time.Time) {
+	if client.ClientUpdated.IsZero() || !latestTimestamp.After(client.ClientUpdated) {
+		return
+	}
+
+	interval := latestTimestamp.Sub(client.ClientUpdated)
+	if client.UpdateInterval == 0 {
+		client.UpdateInterval = interval
+		return
+	}
+
+	client.UpdateInterval = time.Duration(
+		(1-UPDATE_INTERVAL_SMOOTHING)*float64(client.UpdateInterval) + UPDATE_INTERVAL_SMOOTHING*float64(interval),
+	)
+}
+
+// forecastExpiry warns if the client would be expired before the next update expected with the observed cadence
+func (client *Client) forecastExpiry(chainId, clientId string) {
+	alertKey := alert.Key{
+		ChainId:             chainId,
+		CounterpartyChainId: client.ChainId,
+		ClientId:            clientId,
+
+		Condition: ALERT_CLIENT_EXPIRY_FORECAST,
+	}
+
+	nextUpdate, ok := client.NextExpectedUpdate()
+	if !ok {
+		return
+	}
+
+	if nextUpdate.Before(client.ExpiresAt()) {
+		client.ExpiryForecast = false
+
+		msg := fmt.Sprintf("client %s(%s) on %s is expected to be updated before expiry", clientId, client.ChainId, chainId)
+		alert.Resolve(alertKey, msg)
+
+		return
+	}
+
+	client.ExpiryForecast = true
+
+	msg := fmt.Sprintf(
+		"client %s(%s) on %s is updated every %s on average, it would be expired at %s before the next update expected at %s",
+		clientId, client.ChainId, chainId, client.UpdateInterval.Round(time.Second), client.ExpiresAt(), nextUpdate,
+	)
+	logger.Warn(msg)
+	alert.Fire(alertKey, alert.WARNING, msg)
+}
+
 func (client *Client) warnExpiration(latestTimestamp time.Time, warningTime time.Duration) bool {
 	// "expired" means: timestamp + trusting period <= current time
 	warnExpirationTime := latestTimestamp.Add(client.TrustingPeriod).Add(-warningTime)
 	return !warnExpirationTime.After(time.Now().UTC())
 }
+
+// clients with trusting period expire by time
+func (client *Client) Expires() bool {
+	return client.TrustingPeriod != 0
+}
+
+func (client *Client) ExpiresAt() time.Time {
+	return client.ClientUpdated.Add(client.TrustingPeriod)
+}
+
+func (client *Client) TimeToExpiry() time.Duration {
+	return time.Until(client.ExpiresAt())
+}
+
+func (client *Client) TimeSinceUpdate() time.Duration {
+	return time.Since(client.ClientUpdated)
+}
+
+// NextExpectedUpdate returns false until the update cadence is observed
+func (client *Client) NextExpectedUpdate() (time.Time, bool) {
+	if client.UpdateInterval == 0 {
+		return time.Time{}, false
+	}
+
+	return client.ClientUpdated.Add(client.UpdateInterval), true
+}
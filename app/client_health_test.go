@@ -0,0 +1,98 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveUpdate(t *testing.T) {
+	updated := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		client          Client
+		latestTimestamp time.Time
+
+		expected time.Duration
+	}{
+		{
+			name:            "first update is not observed",
+			client:          Client{},
+			latestTimestamp: updated,
+			expected:        0,
+		},
+		{
+			name:            "first interval",
+			client:          Client{ClientUpdated: updated},
+			latestTimestamp: updated.Add(1 * time.Hour),
+			expected:        1 * time.Hour,
+		},
+		{
+			name:            "moving average",
+			client:          Client{ClientUpdated: updated, UpdateInterval: 1 * time.Hour},
+			latestTimestamp: updated.Add(2 * time.Hour),
+			expected:        78 * time.Minute,
+		},
+		{
+			name:            "not updated",
+			client:          Client{ClientUpdated: updated, UpdateInterval: 1 * time.Hour},
+			latestTimestamp: updated,
+			expected:        1 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.client.observeUpdate(test.latestTimestamp)
+			if test.client.UpdateInterval != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, test.client.UpdateInterval)
+			}
+		})
+	}
+}
+
+func TestForecastExpiry(t *testing.T) {
+	updated := time.Now().UTC().Add(-1 * time.Hour)
+
+	tests := []struct {
+		name string
+
+		client Client
+
+		expectedNextUpdate time.Time
+		expectedForecast   bool
+	}{
+		{
+			name:             "cadence is not observed",
+			client:           Client{ClientUpdated: updated, TrustingPeriod: 10 * time.Hour, ExpiryForecast: true},
+			expectedForecast: true,
+		},
+		{
+			name:               "updated before expiry",
+			client:             Client{ClientUpdated: updated, TrustingPeriod: 10 * time.Hour, UpdateInterval: 2 * time.Hour, ExpiryForecast: true},
+			expectedNextUpdate: updated.Add(2 * time.Hour),
+			expectedForecast:   false,
+		},
+		{
+			name:               "expired before the next update",
+			client:             Client{ClientUpdated: updated, TrustingPeriod: 10 * time.Hour, UpdateInterval: 12 * time.Hour},
+			expectedNextUpdate: updated.Add(12 * time.Hour),
+			expectedForecast:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nextUpdate, ok := test.client.NextExpectedUpdate()
+			if ok != !test.expectedNextUpdate.IsZero() || !nextUpdate.Equal(test.expectedNextUpdate) {
+				t.Fatalf("expected next update %s, got %s %t", test.expectedNextUpdate, nextUpdate, ok)
+			}
+
+			test.client.forecastExpiry("src-1", "07-tendermint-0")
+			if test.client.ExpiryForecast != test.expectedForecast {
+				t.Fatalf("expected forecast %t, got %t", test.expectedForecast, test.client.ExpiryForecast)
+			}
+		})
+	}
+}
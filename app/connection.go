@@ -11,40 +11,45 @@ import (
 	connectionTypes "github.com/cosmos/ibc-go/v10/modules/core/03-connection/types"
 )
 
-// set all of open connections
-func (connections *Connections) setOpenConnections(ctx context.Context, grpcClient *grpc.Client, clientId string) error {
+// set channels of open connections, and return states of every connection
+func (connections *Connections) setConnections(ctx context.Context, grpcClient *grpc.Client, clientId string) (map[string]string, error) {
+	states := make(map[string]string)
+
 	clientConnections, err := grpcClient.GetClientConnections(ctx, clientId)
 	if err != nil {
 		if errors.Is(errors.Cause(err), grpc.CONNECTION_NOT_FOUND(clientId)) {
 			msg := fmt.Sprintf("no connection paths found for client %s", clientId)
 			logger.Info(msg)
 
-			return nil
+			return states, nil
 		}
-		return err
+		return nil, err
 	}
 
 	for _, connectionId := range clientConnections {
 		connection, err := grpcClient.GetConnection(ctx, connectionId)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
+		states[connectionId] = stateName(connection.State)
+
+		// channels can be opened only on open connections
 		if connection.State != connectionTypes.OPEN {
-			msg := fmt.Sprintf("skipping connection: %s, only open connections are supported", connectionId)
+			msg := fmt.Sprintf("connection %s is %s, its channels are not discovered", connectionId, states[connectionId])
 			logger.Debug(msg)
 
 			continue
 		}
 
 		channels := make(Channels)
-		err = channels.setOpenChannels(ctx, grpcClient, connectionId, &connection.Counterparty)
+		err = channels.setChannels(ctx, grpcClient, connectionId, &connection.Counterparty)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		(*connections)[connectionId] = channels
 	}
 
-	return nil
+	return states, nil
 }
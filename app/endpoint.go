@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/endpoint"
+	"github.com/dlvlabs/ibcmon/client/grpc"
+	"github.com/dlvlabs/ibcmon/client/rpc"
+)
+
+const (
+	ENDPOINT_RPC  = "rpc"
+	ENDPOINT_GRPC = "grpc"
+)
+
+type (
+	EndpointStatuses []EndpointStatus
+	EndpointStatus   struct {
+		ChainId string
+		// "rpc" or "grpc"
+		Type string
+
+		endpoint.Status
+	}
+)
+
+// rpc_addr is the first endpoint followed by rpc_addrs
+func (endpoints Endpoints) rpcAddrs() []string {
+	var addrs []string
+	if endpoints.RPCAddr != "" {
+		addrs = append(addrs, endpoints.RPCAddr)
+	}
+	return append(addrs, endpoints.RPCAddrs...)
+}
+
+// grpc.addr is the first endpoint followed by grpc.addrs
+func (endpoints Endpoints) grpcAddrs() []string {
+	var addrs []string
+	if endpoints.GRPC.Addr != "" {
+		addrs = append(addrs, endpoints.GRPC.Addr)
+	}
+	return append(addrs, endpoints.GRPC.Addrs...)
+}
+
+// check every endpoint, so the fastest healthy one is used
+func (app *App) runEndpointChecker(ctx context.Context) {
+	// Initialize ticker to fire immediately
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			app.checkEndpoints(ctx)
+
+			// reset ticket
+			ticker.Reset(app.cfg.General.EndpointCheckInterval)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// endpoints are checked without endpointsMutex, so adding endpoints doesn't wait for the checks
+func (app *App) checkEndpoints(ctx context.Context) {
+	app.endpointsMutex.RLock()
+	rpcs, grpcs := maps.Clone(app.rpcs), maps.Clone(app.grpcs)
+	app.endpointsMutex.RUnlock()
+
+	for _, rpc := range rpcs {
+		rpc.CheckEndpoints(ctx)
+	}
+	for _, grpc := range grpcs {
+		grpc.CheckEndpoints(ctx)
+	}
+}
+
+// rpc client of the chain, nil if its endpoints are unknown
+func (app *App) getRPC(chainId string) *rpc.Client {
+	app.endpointsMutex.RLock()
+	defer app.endpointsMutex.RUnlock()
+
+	return app.rpcs[chainId]
+}
+
+// grpc client of the chain, nil if its endpoints are unknown
+func (app *App) getGRPC(chainId string) *grpc.Client {
+	app.endpointsMutex.RLock()
+	defer app.endpointsMutex.RUnlock()
+
+	return app.grpcs[chainId]
+}
+
+func (app *App) EndpointStatuses() EndpointStatuses {
+	app.endpointsMutex.RLock()
+	defer app.endpointsMutex.RUnlock()
+
+	var statuses EndpointStatuses
+	for chainId, rpc := range app.rpcs {
+		for _, status := range rpc.EndpointStatuses() {
+			statuses = append(statuses, EndpointStatus{ChainId: chainId, Type: ENDPOINT_RPC, Status: status})
+		}
+	}
+	for chainId, grpc := range app.grpcs {
+		for _, status := range grpc.EndpointStatuses() {
+			statuses = append(statuses, EndpointStatus{ChainId: chainId, Type: ENDPOINT_GRPC, Status: status})
+		}
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].ChainId != statuses[j].ChainId {
+			return statuses[i].ChainId < statuses[j].ChainId
+		}
+		return statuses[i].Type > statuses[j].Type
+	})
+
+	return statuses
+}
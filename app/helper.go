@@ -4,9 +4,31 @@ import (
 	"time"
 )
 
+// connectGRPCs locks grpcs until terminateGRPCs, the lock is released if it fails
 func (app *App) connectGRPCs() error {
 	app.grpcsMutex.Lock()
 
+	err := app.openGRPCs()
+	if err != nil {
+		// close the connections already made
+		_ = app.closeGRPCs()
+		app.grpcsMutex.Unlock()
+
+		return err
+	}
+	return nil
+}
+
+func (app *App) terminateGRPCs() error {
+	defer app.grpcsMutex.Unlock()
+
+	return app.closeGRPCs()
+}
+
+func (app *App) openGRPCs() error {
+	app.endpointsMutex.RLock()
+	defer app.endpointsMutex.RUnlock()
+
 	for _, grpc := range app.grpcs {
 		err := grpc.Connect()
 		if err != nil {
@@ -16,17 +38,20 @@ func (app *App) connectGRPCs() error {
 	return nil
 }
 
-func (app *App) terminateGRPCs() error {
+// every connection is closed even if some of them fail, the first error is returned
+func (app *App) closeGRPCs() error {
+	app.endpointsMutex.RLock()
+	defer app.endpointsMutex.RUnlock()
+
+	var firstErr error
 	for _, grpc := range app.grpcs {
 		err := grpc.Terminate()
-		if err != nil {
-			return err
+		if err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
 
-	app.grpcsMutex.Unlock()
-
-	return nil
+	return firstErr
 }
 
 func (app *App) updateStore(update func()) {
@@ -35,4 +60,5 @@ func (app *App) updateStore(update func()) {
 
 	update()
 	app.Store.Updated = time.Now().UTC()
+	app.publish()
 }
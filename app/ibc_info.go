@@ -3,13 +3,25 @@ package app
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/dlvlabs/ibcmon/client/grpc"
+	"github.com/dlvlabs/ibcmon/client/rpc"
 	"github.com/dlvlabs/ibcmon/logger"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	// discovery fails if endpoints of a counterparty are not found
+	UNKNOWN_COUNTERPARTY_ERROR = "error"
+	// paths to the counterparty are kept in ibc info without tracking packets
+	UNKNOWN_COUNTERPARTY_UNMONITORED = "unmonitored"
+)
+
 type (
 	// chainid => Clients
 	IBCInfo map[string]Clients
@@ -19,16 +31,35 @@ type (
 
 	Client struct {
 		Health bool
+		// "Active", "Expired", "Frozen", "Unknown" or "Unauthorized"
+		Status string
+		// status of the last health check, which the transitions are alerted from
+		CheckedStatus string
+		// e.g. "07-tendermint", "06-solomachine", "08-wasm"
+		ClientType string
 
-		ChainId        string
+		// empty if the counterparty is not a chain, e.g. solo machine
+		ChainId string
+		// true if endpoints of the counterparty chain are not found, so its paths are not tracked
+		Unmonitored    bool
 		RevisionNumber uint64
 		RevisionHeight uint64
 		TrustingPeriod time.Duration
 
-		// this value updated by app.checkClientHealth
+		// these values updated by app.checkClientHealth
 		ClientUpdated time.Time
+		// moving average of the intervals between observed client updates
+		UpdateInterval time.Duration
+		// true if the client would be expired before the next expected update
+		ExpiryForecast bool
+		// set while checking or discovering the client fails, the values above are of the last success
+		Degraded *Degradation
 
 		Connections Connections
+		// connectionId => state, including the connections which are not open
+		ConnectionStates map[string]string
+		// IBC v2 path to the counterparty client, nil if the counterparty is not registered
+		V2 *Channel
 	}
 
 	// connectionId => Channels
@@ -37,11 +68,23 @@ type (
 	// channelId => Channel
 	Channels map[string]*Channel
 	Channel  struct {
+		// IBC v2 routes packets from client to client,
+		// so the channel ids are the client ids and the port ids are empty
+		V2 bool
+
 		PortId       string
 		Counterparty *Counterparty
 
-		// this value updated by app.trackIBCPacket
-		IBCPacketTracker *IBCPacketTracker
+		// e.g. "OPEN", "FLUSHING" or "CLOSED", see Channel.GetState
+		State string
+		// when the channel upgrade started flushing, zero unless FLUSHING or FLUSHCOMPLETE
+		FlushingSince time.Time
+
+		// this value updated by app.syncIBCPacketTrackers
+		IBCPacketTracker *IBCPacketTracker `json:"-"`
+
+		// this value updated by app.checkUnrelayedPackets
+		Unrelayed *Unrelayed
 	}
 	Counterparty struct {
 		ClientId     string
@@ -49,6 +92,13 @@ type (
 		ChannelId    string
 		PortId       string
 	}
+
+	// a channel of the client, or the IBC v2 path of the client without connection
+	ChannelPath struct {
+		ConnectionId string
+		ChannelId    string
+		Channel      *Channel
+	}
 )
 
 func (app *App) initIBCInfo(ctx context.Context) error {
@@ -66,61 +116,176 @@ func (app *App) initIBCInfo(ctx context.Context) error {
 		}
 	}()
 
-	err = app.setBaseChain(ctx)
-	if err != nil {
-		return err
+	// previous clients are kept even if they are not active anymore
+	prev := app.ibcInfo()
+
+	for _, baseChainId := range app.cfg.General.baseChainIds {
+		err = app.setBaseChain(ctx, baseChainId, prev)
+		if err == nil {
+			continue
+		}
+
+		// the base chain which was discovered before keeps its ibc info, so the others are still updated
+		if _, ok := prev[baseChainId]; !ok {
+			return err
+		}
+		logger.Error(errors.Wrapf(err, "failed to discover base chain %s, keep the previous ibc info", baseChainId))
+		app.updateStore(func() { prev[baseChainId].degrade(err) })
 	}
 
-	err = app.setCounterparties(ctx)
+	err = app.setCounterparties(ctx, prev)
 	if err != nil {
 		return err
 	}
 
-	logger.Debug(fmt.Sprintf("IBCInfo: %s", app.Store.IBCInfo))
+	logger.Debug(fmt.Sprintf("IBCInfo: %v", app.ibcInfo()))
 
 	return nil
 }
 
-func (app *App) setBaseChain(ctx context.Context) error {
-	msg := fmt.Sprintf("init ibc info for basechain(%s)", app.cfg.General.baseChainId)
+func (app *App) setBaseChain(ctx context.Context, baseChainId string, prev IBCInfo) error {
+	msg := fmt.Sprintf("init ibc info for basechain(%s)", baseChainId)
 	logger.Info(msg)
 
 	clients := make(Clients)
-	err := clients.setActiveClients(ctx, app.grpcs[app.cfg.General.baseChainId], app.cdc)
+	err := clients.setClients(ctx, app.getGRPC(baseChainId), app.cdc, prev[baseChainId])
 	if err != nil {
 		return err
 	}
-	app.updateStore(func() { app.Store.IBCInfo[app.cfg.General.baseChainId] = clients })
+
+	for clientId, client := range clients {
+		if client.hasCounterpartyChain() || client.V2 == nil {
+			continue
+		}
+
+		client.ChainId = app.resolveV2Counterparty(ctx, baseChainId, clientId, client.V2.Counterparty.ClientId)
+	}
+	app.replaceClients(baseChainId, clients)
 
 	return nil
 }
 
-func (app *App) setCounterparties(ctx context.Context) error {
-	g, ctx := errgroup.WithContext(ctx)
+// chain id of the client which doesn't decode its counterparty chain, e.g. 08-wasm of a non-tendermint contract,
+// the counterparty is the chain whose IBC v2 counterparty info of counterpartyClientId is the client.
+// empty if it's not found among the chains with endpoints in config file, or found on many of them
+func (app *App) resolveV2Counterparty(ctx context.Context, chainId, clientId, counterpartyClientId string) string {
+	app.endpointsMutex.RLock()
+	grpcs := maps.Clone(app.grpcs)
+	app.endpointsMutex.RUnlock()
 
-	for _, client := range app.Store.IBCInfo[app.cfg.General.baseChainId] {
-		chainId := client.ChainId
+	var found []string
+	for counterpartyChainId, grpc := range grpcs {
+		if counterpartyChainId == chainId {
+			continue
+		}
 
-		// check whether the endpoint is in the config file
-		_, ok := app.cfg.Counterparties[chainId]
-		if !ok {
-			msg := fmt.Sprintf("missing counterparty endpoints in config file for %s", chainId)
-			return errors.New(msg)
+		// failure of a chain doesn't stop resolving with the others
+		registered, err := grpc.GetCounterpartyInfo(ctx, counterpartyClientId)
+		if err != nil {
+			logger.Warn(errors.Wrapf(err, "failed to resolve counterparty chain of client %s on %s", clientId, chainId))
+			continue
+		}
+		if registered == clientId {
+			found = append(found, counterpartyChainId)
 		}
+	}
+
+	if len(found) != 1 {
+		msg := fmt.Sprintf("counterparty chain of client %s on %s is not resolved with IBC v2 counterparty info: %v", clientId, chainId, found)
+		logger.Debug(msg)
+
+		return ""
+	}
+
+	msg := fmt.Sprintf("resolved counterparty chain of client %s on %s with IBC v2 counterparty info: %s", clientId, chainId, found[0])
+	logger.Info(msg)
+
+	return found[0]
+}
+
+func (app *App) setCounterparties(ctx context.Context, prev IBCInfo) error {
+	g, ctx := errgroup.WithContext(ctx)
+
+	// clients of every counterparty are collected first, because a chain can be the counterparty of many paths
+	var mutex sync.Mutex
+	counterparties := make(IBCInfo)
+	// chainId/counterpartyClientId, a counterparty client is discovered once even if it has many paths
+	discovered := make(map[string]bool)
+
+	ibcInfo := app.ibcInfo()
+	for _, baseChainId := range app.cfg.General.baseChainIds {
+		for clientId, client := range ibcInfo[baseChainId] {
+			chainId := client.ChainId
+			if !client.hasCounterpartyChain() {
+				msg := fmt.Sprintf("skipping counterparty of %s client, the counterparty is not a chain", client.ClientType)
+				logger.Debug(msg)
+
+				continue
+			}
+
+			// paths between base chains are discovered from both sides
+			if slices.Contains(app.cfg.General.baseChainIds, chainId) {
+				continue
+			}
+
+			// check whether the endpoint is in the config file or the chain registry
+			ok, err := app.resolveCounterparty(chainId)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				if app.cfg.General.UnknownCounterparty != UNKNOWN_COUNTERPARTY_UNMONITORED {
+					msg := fmt.Sprintf("missing counterparty endpoints in config file for %s", chainId)
+					return errors.New(msg)
+				}
+
+				msg := fmt.Sprintf("missing counterparty endpoints for %s, client %s is unmonitored", chainId, clientId)
+				logger.Warn(msg)
+
+				app.updateStore(func() { client.Unmonitored = true })
+
+				continue
+			}
+
+			for _, path := range client.Paths(clientId) {
+				counterpartyClientId := path.Channel.Counterparty.ClientId
+
+				key := fmt.Sprintf("%s/%s", chainId, counterpartyClientId)
+				if discovered[key] {
+					continue
+				}
+				discovered[key] = true
 
-		for _, channels := range client.Connections {
-			for _, channel := range channels {
 				g.Go(func() error {
 					msg := fmt.Sprintf("init ibc info for counterparty(%s)", chainId)
 					logger.Info(msg)
 
 					clients := make(Clients)
-					err := clients.setActiveClient(ctx, app.grpcs[chainId], app.cdc, channel.Counterparty.ClientId)
+					err := clients.setCounterpartyClient(ctx, app.getGRPC(chainId), app.cdc, counterpartyClientId, prev[chainId])
+					// the counterparty of the counterparty client is the base chain, even if its client state doesn't tell
+					if counterpartyClient, ok := clients[counterpartyClientId]; err == nil && ok && !counterpartyClient.hasCounterpartyChain() {
+						counterpartyClient.ChainId = baseChainId
+					}
 					if err != nil {
-						logger.Error(err)
-						return err
+						// failure of a counterparty doesn't stop discovering the others,
+						// the client discovered before is kept
+						logger.Error(errors.Wrapf(err, "failed to discover client %s of counterparty(%s)", counterpartyClientId, chainId))
+
+						prevClient, ok := prev[chainId][counterpartyClientId]
+						if !ok {
+							return nil
+						}
+						app.updateStore(func() { prevClient.Degraded = prevClient.Degraded.update(err) })
+						clients[counterpartyClientId] = prevClient
+					}
+
+					mutex.Lock()
+					defer mutex.Unlock()
+
+					if counterparties[chainId] == nil {
+						counterparties[chainId] = make(Clients)
 					}
-					app.updateStore(func() { app.Store.IBCInfo[chainId] = clients })
+					maps.Copy(counterparties[chainId], clients)
 
 					return nil
 				})
@@ -128,5 +293,99 @@ func (app *App) setCounterparties(ctx context.Context) error {
 		}
 	}
 
-	return g.Wait()
+	err := g.Wait()
+	if err != nil {
+		return err
+	}
+
+	for chainId, clients := range counterparties {
+		app.replaceClients(chainId, clients)
+	}
+
+	return nil
+}
+
+func (clients Clients) degrade(err error) {
+	for _, client := range clients {
+		client.Degraded = client.Degraded.update(err)
+	}
+}
+
+// packets of the clients without counterparty chain can't be tracked
+func (client *Client) hasCounterpartyChain() bool {
+	return client.ChainId != ""
+}
+
+// packets are tracked only through the clients whose counterparty chain has endpoints
+func (client *Client) isMonitored() bool {
+	return client.hasCounterpartyChain() && !client.Unmonitored
+}
+
+// app.storeMutex is held for Unmonitored set by app.setCounterparties
+func (app *App) isMonitored(client *Client) bool {
+	app.storeMutex.Lock()
+	defer app.storeMutex.Unlock()
+
+	return client.isMonitored()
+}
+
+// resolve endpoints of the counterparty from the chain registry if it's missing in config file,
+// return false if the endpoints are not found
+func (app *App) resolveCounterparty(chainId string) (bool, error) {
+	if app.getGRPC(chainId) != nil {
+		return true, nil
+	}
+	if app.registry == nil {
+		return false, nil
+	}
+
+	endpoints, ok := app.registry.Endpoints(chainId)
+	if !ok {
+		return false, nil
+	}
+
+	rpcClient, err := rpc.New(endpoints.RPCAddrs, app.cfg.Retry)
+	if err != nil {
+		return false, err
+	}
+	// grpcs are already connected in this cycle
+	grpcClient := grpc.New(endpoints.GRPCAddrs, endpoints.TLSConn, app.cfg.Retry)
+	err = grpcClient.Connect()
+	if err != nil {
+		return false, err
+	}
+
+	// running trackers keep the clients they were created with, the other jobs read them with app.getRPC and app.getGRPC
+	app.endpointsMutex.Lock()
+	app.rpcs[chainId] = rpcClient
+	app.grpcs[chainId] = grpcClient
+	app.endpointsMutex.Unlock()
+
+	msg := fmt.Sprintf("resolved counterparty endpoints from chain registry for %s: %v, %v", chainId, endpoints.RPCAddrs, endpoints.GRPCAddrs)
+	logger.Info(msg)
+
+	return true, nil
+}
+
+// return every channel of the client including the IBC v2 path
+func (client *Client) Paths(clientId string) []ChannelPath {
+	var paths []ChannelPath
+	for connectionId, channels := range client.Connections {
+		for channelId, channel := range channels {
+			paths = append(paths, ChannelPath{
+				ConnectionId: connectionId,
+				ChannelId:    channelId,
+				Channel:      channel,
+			})
+		}
+	}
+
+	if client.V2 != nil {
+		paths = append(paths, ChannelPath{
+			ChannelId: clientId,
+			Channel:   client.V2,
+		})
+	}
+
+	return paths
 }
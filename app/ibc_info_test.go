@@ -0,0 +1,72 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestPaths(t *testing.T) {
+	v1 := &Channel{PortId: "transfer"}
+	v2 := &Channel{V2: true}
+
+	tests := []struct {
+		name string
+
+		client *Client
+
+		// connection id/channel id => channel
+		expected map[string]*Channel
+	}{
+		{
+			name: "channels of v1",
+			client: &Client{
+				Connections: Connections{
+					"connection-0": Channels{"channel-0": v1, "channel-1": v1},
+					"connection-1": Channels{"channel-2": v1},
+				},
+			},
+			expected: map[string]*Channel{
+				"connection-0/channel-0": v1,
+				"connection-0/channel-1": v1,
+				"connection-1/channel-2": v1,
+			},
+		},
+		{
+			name: "client of v2 without connections",
+			client: &Client{
+				Connections: Connections{},
+				V2:          v2,
+			},
+			expected: map[string]*Channel{
+				"/07-tendermint-0": v2,
+			},
+		},
+		{
+			name: "both of v1 and v2",
+			client: &Client{
+				Connections: Connections{
+					"connection-0": Channels{"channel-0": v1},
+				},
+				V2: v2,
+			},
+			expected: map[string]*Channel{
+				"connection-0/channel-0": v1,
+				"/07-tendermint-0":       v2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paths := test.client.Paths("07-tendermint-0")
+			if len(paths) != len(test.expected) {
+				t.Fatalf("expected %d paths, got %d", len(test.expected), len(paths))
+			}
+			for _, path := range paths {
+				key := path.ConnectionId + "/" + path.ChannelId
+				if test.expected[key] != path.Channel {
+					t.Fatalf("unexpected path: %s", key)
+				}
+			}
+		})
+	}
+}
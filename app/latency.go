@@ -0,0 +1,76 @@
+package app
+
+import (
+	"slices"
+	"time"
+)
+
+// phases of relaying a packet which latencies are observed for
+const (
+	LATENCY_SEND_TO_RECV = "send_to_recv"
+	LATENCY_RECV_TO_ACK  = "recv_to_ack"
+	LATENCY_SEND_TO_ACK  = "send_to_ack"
+)
+
+// upper bounds of latency histogram buckets in seconds
+var LATENCY_BUCKETS = []float64{5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+
+type (
+	// phase => LatencyHistogram
+	LatencyHistograms map[string]LatencyHistogram
+	// cumulative histogram of latencies between block times of the packet txs since tracking started
+	LatencyHistogram struct {
+		Count uint64
+		// seconds
+		Sum float64
+		// number of latencies less than or equal to LATENCY_BUCKETS[i]
+		Buckets []uint64
+	}
+)
+
+// the caller should hold the lock of the tracker
+func (histograms LatencyHistograms) observe(phase string, latency time.Duration) {
+	// block times of different chains can be skewed
+	seconds := max(latency.Seconds(), 0)
+
+	histogram := histograms[phase]
+	// buckets are reset if LATENCY_BUCKETS is changed from the restored state
+	if len(histogram.Buckets) != len(LATENCY_BUCKETS) {
+		histogram = LatencyHistogram{Buckets: make([]uint64, len(LATENCY_BUCKETS))}
+	}
+
+	histogram.Count++
+	histogram.Sum += seconds
+	for i, bound := range LATENCY_BUCKETS {
+		if seconds <= bound {
+			histogram.Buckets[i]++
+		}
+	}
+
+	histograms[phase] = histogram
+}
+
+func (histograms LatencyHistograms) copy() LatencyHistograms {
+	copied := make(LatencyHistograms, len(histograms))
+	for phase, histogram := range histograms {
+		histogram.Buckets = slices.Clone(histogram.Buckets)
+		copied[phase] = histogram
+	}
+	return copied
+}
+
+// block time of the event, or the time it's observed if the block time is unknown
+func eventTime(blockTime, now time.Time) time.Time {
+	if blockTime.IsZero() {
+		return now
+	}
+	return blockTime
+}
+
+// latency between block times of two packet txs, false if either block time is unknown
+func blockTimeLatency(from, to time.Time) (time.Duration, bool) {
+	if from.IsZero() || to.IsZero() {
+		return 0, false
+	}
+	return to.Sub(from), true
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		name string
+
+		histogram LatencyHistogram
+		latencies []time.Duration
+
+		expected LatencyHistogram
+	}{
+		{
+			name:      "cumulative buckets",
+			latencies: []time.Duration{3 * time.Second, 45 * time.Second, 2 * time.Hour},
+			expected: LatencyHistogram{
+				Count:   3,
+				Sum:     3 + 45 + 7200,
+				Buckets: []uint64{1, 1, 1, 2, 2, 2, 2, 2, 2},
+			},
+		},
+		{
+			name:      "bound is inclusive",
+			latencies: []time.Duration{10 * time.Second},
+			expected: LatencyHistogram{
+				Count:   1,
+				Sum:     10,
+				Buckets: []uint64{0, 1, 1, 1, 1, 1, 1, 1, 1},
+			},
+		},
+		{
+			name:      "negative latency from skewed block times is zero",
+			latencies: []time.Duration{-3 * time.Second},
+			expected: LatencyHistogram{
+				Count:   1,
+				Sum:     0,
+				Buckets: []uint64{1, 1, 1, 1, 1, 1, 1, 1, 1},
+			},
+		},
+		{
+			name:      "restored with other buckets",
+			histogram: LatencyHistogram{Count: 5, Sum: 100, Buckets: []uint64{5}},
+			latencies: []time.Duration{3 * time.Second},
+			expected: LatencyHistogram{
+				Count:   1,
+				Sum:     3,
+				Buckets: []uint64{1, 1, 1, 1, 1, 1, 1, 1, 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			histograms := LatencyHistograms{LATENCY_SEND_TO_RECV: test.histogram}
+			for _, latency := range test.latencies {
+				histograms.observe(LATENCY_SEND_TO_RECV, latency)
+			}
+
+			result := histograms[LATENCY_SEND_TO_RECV]
+			if result.Count != test.expected.Count || result.Sum != test.expected.Sum || !slices.Equal(result.Buckets, test.expected.Buckets) {
+				t.Fatalf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestBlockTimeLatency(t *testing.T) {
+	blockTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		from time.Time
+		to   time.Time
+
+		expected         time.Duration
+		expectedMeasured bool
+	}{
+		{name: "measured", from: blockTime, to: blockTime.Add(1 * time.Minute), expected: 1 * time.Minute, expectedMeasured: true},
+		{name: "skewed", from: blockTime, to: blockTime.Add(-1 * time.Second), expected: -1 * time.Second, expectedMeasured: true},
+		{name: "unknown from", to: blockTime, expectedMeasured: false},
+		{name: "unknown to", from: blockTime, expectedMeasured: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			latency, measured := blockTimeLatency(test.from, test.to)
+			if latency != test.expected || measured != test.expectedMeasured {
+				t.Fatalf("expected %s %t, got %s %t", test.expected, test.expectedMeasured, latency, measured)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/grpc"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
+	"github.com/cosmos/ibc-go/v10/modules/core/exported"
+	solomachine "github.com/cosmos/ibc-go/v10/modules/light-clients/06-solomachine"
+	tendermint "github.com/cosmos/ibc-go/v10/modules/light-clients/07-tendermint"
+)
+
+// LightClient is a client state decoded by its light client handler
+type LightClient interface {
+	ClientType() string
+	// chain id of the counterparty, empty if the counterparty is not a chain tracked by ibcmon
+	ChainId() string
+	LatestHeight() (revisionNumber, revisionHeight uint64)
+	// 0 if the client doesn't expire by time
+	TrustingPeriod() time.Duration
+	// timestamp of the latest consensus state, zero if the client type doesn't provide it
+	ConsensusTimestamp(ctx context.Context, grpc *grpc.Client, cdc codectypes.InterfaceRegistry, clientId string) (time.Time, error)
+}
+
+type lightClientHandler struct {
+	registerInterfaces func(codectypes.InterfaceRegistry)
+	newLightClient     func(exported.ClientState) (LightClient, bool)
+}
+
+// client state type url => handler,
+// client states of the other types are handled by genericClient
+var lightClientHandlers = map[string]lightClientHandler{
+	"/ibc.lightclients.tendermint.v1.ClientState": {
+		registerInterfaces: tendermint.RegisterInterfaces,
+		newLightClient: func(cs exported.ClientState) (LightClient, bool) {
+			clientState, ok := cs.(*tendermint.ClientState)
+			return &tendermintClient{clientState}, ok
+		},
+	},
+	"/ibc.lightclients.solomachine.v3.ClientState": {
+		registerInterfaces: solomachine.RegisterInterfaces,
+		newLightClient: func(cs exported.ClientState) (LightClient, bool) {
+			clientState, ok := cs.(*solomachine.ClientState)
+			return &soloMachineClient{clientState}, ok
+		},
+	},
+}
+
+func registerLightClients(cdc codectypes.InterfaceRegistry) {
+	for _, handler := range lightClientHandlers {
+		handler.registerInterfaces(cdc)
+	}
+}
+
+func newLightClient(cdc codectypes.InterfaceRegistry, clientId string, state *codectypes.Any) (LightClient, error) {
+	if state.TypeUrl == WASM_CLIENT_STATE_TYPE_URL {
+		return newWasmClient(clientId, state.Value)
+	}
+
+	handler, ok := lightClientHandlers[state.TypeUrl]
+	if !ok {
+		return &genericClient{clientType: clientType(clientId)}, nil
+	}
+
+	var iClientState exported.ClientState
+	if err := cdc.UnpackAny(state, &iClientState); err != nil {
+		return nil, errors.Wrapf(err, "failed to unpack client state for client: %s", clientId)
+	}
+
+	lightClient, ok := handler.newLightClient(iClientState)
+	if !ok {
+		return nil, errors.Errorf("invalid client state type: %T", iClientState)
+	}
+
+	return lightClient, nil
+}
+
+// client type is the prefix of client id, e.g. "08-wasm" of "08-wasm-3"
+func clientType(clientId string) string {
+	i := strings.LastIndex(clientId, "-")
+	if i < 0 {
+		return clientId
+	}
+
+	clientType := clientId[:i]
+	if !strings.Contains(clientType, "-") {
+		// sentinel client id without sequence, e.g. "09-localhost"
+		return clientId
+	}
+
+	return clientType
+}
+
+type tendermintClient struct {
+	clientState *tendermint.ClientState
+}
+
+func (c *tendermintClient) ClientType() string {
+	return exported.Tendermint
+}
+
+func (c *tendermintClient) ChainId() string {
+	return c.clientState.ChainId
+}
+
+func (c *tendermintClient) LatestHeight() (uint64, uint64) {
+	return c.clientState.LatestHeight.RevisionNumber, c.clientState.LatestHeight.RevisionHeight
+}
+
+func (c *tendermintClient) TrustingPeriod() time.Duration {
+	return c.clientState.TrustingPeriod
+}
+
+func (c *tendermintClient) ConsensusTimestamp(
+	ctx context.Context,
+	grpc *grpc.Client,
+	cdc codectypes.InterfaceRegistry,
+	clientId string,
+) (time.Time, error) {
+	state, err := grpc.GetConsensusState(ctx, clientId, c.clientState.LatestHeight.RevisionNumber, c.clientState.LatestHeight.RevisionHeight)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var iConsensusState exported.ConsensusState
+	if err := cdc.UnpackAny(state, &iConsensusState); err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to unpack consensus state for client: %s", clientId)
+	}
+	consensusState, ok := iConsensusState.(*tendermint.ConsensusState)
+	if !ok {
+		return time.Time{}, errors.Errorf("invalid consensus state type: %T", iConsensusState)
+	}
+
+	return consensusState.Timestamp, nil
+}
+
+// solo machine is not a chain and doesn't expire, it's only frozen on misbehaviour
+type soloMachineClient struct {
+	clientState *solomachine.ClientState
+}
+
+func (c *soloMachineClient) ClientType() string {
+	return exported.Solomachine
+}
+
+func (c *soloMachineClient) ChainId() string {
+	return ""
+}
+
+func (c *soloMachineClient) LatestHeight() (uint64, uint64) {
+	return 0, c.clientState.Sequence
+}
+
+func (c *soloMachineClient) TrustingPeriod() time.Duration {
+	return 0
+}
+
+func (c *soloMachineClient) ConsensusTimestamp(context.Context, *grpc.Client, codectypes.InterfaceRegistry, string) (time.Time, error) {
+	if c.clientState.ConsensusState == nil {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(0, int64(c.clientState.ConsensusState.Timestamp)).UTC(), nil
+}
+
+// client types which can't be decoded, e.g. 09-localhost, are checked with their status only,
+// their counterparty chain is resolved with IBC v2 counterparty info if it's registered
+type genericClient struct {
+	clientType string
+}
+
+func (c *genericClient) ClientType() string {
+	return c.clientType
+}
+
+func (c *genericClient) ChainId() string {
+	return ""
+}
+
+func (c *genericClient) LatestHeight() (uint64, uint64) {
+	return 0, 0
+}
+
+func (c *genericClient) TrustingPeriod() time.Duration {
+	return 0
+}
+
+func (c *genericClient) ConsensusTimestamp(context.Context, *grpc.Client, codectypes.InterfaceRegistry, string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+const (
+	WASM_CLIENT_TYPE                    = "08-wasm"
+	WASM_CLIENT_STATE_TYPE_URL          = "/ibc.lightclients.wasm.v1.ClientState"
+	WASM_CONSENSUS_STATE_TYPE_URL       = "/ibc.lightclients.wasm.v1.ConsensusState"
+	TENDERMINT_CLIENT_STATE_TYPE_URL    = "/ibc.lightclients.tendermint.v1.ClientState"
+	TENDERMINT_CONSENSUS_STATE_TYPE_URL = "/ibc.lightclients.tendermint.v1.ConsensusState"
+)
+
+// 08-wasm wraps the client state of a contract, e.g. a tendermint light client compiled to wasm.
+// it's decoded without the 08-wasm module, which requires cgo and libwasmvm
+type wasmClient struct {
+	latestHeight clientTypes.Height
+	// client state in the data of the contract, nil if it's not a tendermint client
+	tendermint *tendermint.ClientState
+}
+
+// ClientState of ibc.lightclients.wasm.v1: data = 1, checksum = 2, latest_height = 3
+func newWasmClient(clientId string, value []byte) (*wasmClient, error) {
+	fields, err := decodeFields(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode wasm client state for client: %s", clientId)
+	}
+
+	client := &wasmClient{}
+	if latestHeight, ok := fields[3]; ok {
+		err := client.latestHeight.Unmarshal(latestHeight)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode latest height of wasm client: %s", clientId)
+		}
+	}
+
+	clientState := &tendermint.ClientState{}
+	// the receiver of the validation is bound after decoding
+	validate := func() error { return clientState.Validate() }
+	if decodeContractData(fields[1], TENDERMINT_CLIENT_STATE_TYPE_URL, clientState, validate) && clientState.ChainId != "" {
+		client.tendermint = clientState
+	}
+
+	return client, nil
+}
+
+func (c *wasmClient) ClientType() string {
+	return WASM_CLIENT_TYPE
+}
+
+func (c *wasmClient) ChainId() string {
+	if c.tendermint == nil {
+		return ""
+	}
+	return c.tendermint.ChainId
+}
+
+func (c *wasmClient) LatestHeight() (uint64, uint64) {
+	return c.latestHeight.RevisionNumber, c.latestHeight.RevisionHeight
+}
+
+func (c *wasmClient) TrustingPeriod() time.Duration {
+	if c.tendermint == nil {
+		return 0
+	}
+	return c.tendermint.TrustingPeriod
+}
+
+// ConsensusState of ibc.lightclients.wasm.v1: data = 1
+func (c *wasmClient) ConsensusTimestamp(
+	ctx context.Context,
+	grpc *grpc.Client,
+	cdc codectypes.InterfaceRegistry,
+	clientId string,
+) (time.Time, error) {
+	if c.tendermint == nil {
+		return time.Time{}, nil
+	}
+
+	state, err := grpc.GetConsensusState(ctx, clientId, c.latestHeight.RevisionNumber, c.latestHeight.RevisionHeight)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if state.TypeUrl != WASM_CONSENSUS_STATE_TYPE_URL {
+		return time.Time{}, errors.Errorf("invalid consensus state type: %s", state.TypeUrl)
+	}
+
+	fields, err := decodeFields(state.Value)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to decode wasm consensus state for client: %s", clientId)
+	}
+
+	consensusState := &tendermint.ConsensusState{}
+	validate := func() error { return consensusState.ValidateBasic() }
+	if !decodeContractData(fields[1], TENDERMINT_CONSENSUS_STATE_TYPE_URL, consensusState, validate) {
+		return time.Time{}, nil
+	}
+
+	return consensusState.Timestamp, nil
+}
+
+// contracts store the wrapped state either as an Any or as the message itself,
+// the message itself is accepted only if it's valid since the data of other contracts could be unmarshalled into it
+func decodeContractData(
+	data []byte,
+	typeUrl string,
+	message interface{ Unmarshal([]byte) error },
+	validate func() error,
+) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	wrapped := &codectypes.Any{}
+	if wrapped.Unmarshal(data) == nil && wrapped.TypeUrl == typeUrl {
+		return message.Unmarshal(wrapped.Value) == nil
+	}
+
+	return message.Unmarshal(data) == nil && validate() == nil
+}
+
+// bytes fields of a protobuf message by field number, the other wire types are skipped
+func decodeFields(value []byte) (map[protowire.Number][]byte, error) {
+	fields := make(map[protowire.Number][]byte)
+	for len(value) > 0 {
+		number, wireType, n := protowire.ConsumeTag(value)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		value = value[n:]
+
+		if wireType == protowire.BytesType {
+			field, n := protowire.ConsumeBytes(value)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			fields[number] = field
+			value = value[n:]
+
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(number, wireType, value)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		value = value[n:]
+	}
+
+	return fields, nil
+}
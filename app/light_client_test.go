@@ -0,0 +1,205 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
+	commitmentTypes "github.com/cosmos/ibc-go/v10/modules/core/23-commitment/types"
+	tendermint "github.com/cosmos/ibc-go/v10/modules/light-clients/07-tendermint"
+)
+
+func newTestTendermintState(t *testing.T, trustingPeriod time.Duration) []byte {
+	t.Helper()
+
+	clientState := tendermint.NewClientState(
+		"dst-1", tendermint.DefaultTrustLevel,
+		trustingPeriod, 21*24*time.Hour, 10*time.Second,
+		clientTypes.NewHeight(1, 100), commitmentTypes.GetSDKSpecs(), []string{"upgrade", "upgradedIBCState"},
+	)
+	bz, err := clientState.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal client state: %v", err)
+	}
+	return bz
+}
+
+// ClientState of ibc.lightclients.wasm.v1 with the data of the contract
+func newTestWasmState(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	latestHeight := clientTypes.NewHeight(1, 100)
+	height, err := latestHeight.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal latest height: %v", err)
+	}
+
+	var value []byte
+	if data != nil {
+		value = protowire.AppendTag(value, 1, protowire.BytesType)
+		value = protowire.AppendBytes(value, data)
+	}
+	value = protowire.AppendTag(value, 2, protowire.BytesType)
+	value = protowire.AppendBytes(value, []byte("checksum"))
+	value = protowire.AppendTag(value, 3, protowire.BytesType)
+	value = protowire.AppendBytes(value, height)
+	return value
+}
+
+func TestDecodeFields(t *testing.T) {
+	var message []byte
+	message = protowire.AppendTag(message, 1, protowire.BytesType)
+	message = protowire.AppendBytes(message, []byte("data"))
+	message = protowire.AppendTag(message, 2, protowire.VarintType)
+	message = protowire.AppendVarint(message, 100)
+	message = protowire.AppendTag(message, 3, protowire.BytesType)
+	message = protowire.AppendBytes(message, []byte("height"))
+
+	tests := []struct {
+		name string
+
+		value []byte
+
+		expected map[protowire.Number]string
+		err      bool
+	}{
+		{
+			name:     "bytes fields",
+			value:    message,
+			expected: map[protowire.Number]string{1: "data", 3: "height"},
+		},
+		{
+			name:     "empty",
+			value:    nil,
+			expected: map[protowire.Number]string{},
+		},
+		{
+			name:  "truncated",
+			value: message[:len(message)-2],
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, err := decodeFields(test.value)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+			if test.err {
+				return
+			}
+			if len(fields) != len(test.expected) {
+				t.Fatalf("expected %d fields, got %d", len(test.expected), len(fields))
+			}
+			for number, expected := range test.expected {
+				if string(fields[number]) != expected {
+					t.Fatalf("expected field %d to be %q, got %q", number, expected, fields[number])
+				}
+			}
+		})
+	}
+}
+
+func TestNewWasmClient(t *testing.T) {
+	wrapped, err := (&codectypes.Any{
+		TypeUrl: TENDERMINT_CLIENT_STATE_TYPE_URL,
+		Value:   newTestTendermintState(t, 14*24*time.Hour),
+	}).Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal any: %v", err)
+	}
+
+	// data of another contract which is unmarshalled into a tendermint client state without errors
+	var otherData []byte
+	otherData = protowire.AppendTag(otherData, 1, protowire.BytesType)
+	otherData = protowire.AppendBytes(otherData, []byte("ethereum"))
+
+	tests := []struct {
+		name string
+
+		value []byte
+
+		expectedChainId        string
+		expectedTrustingPeriod time.Duration
+		err                    bool
+	}{
+		{
+			name:                   "tendermint state wrapped in any",
+			value:                  newTestWasmState(t, wrapped),
+			expectedChainId:        "dst-1",
+			expectedTrustingPeriod: 14 * 24 * time.Hour,
+		},
+		{
+			name:                   "valid tendermint state",
+			value:                  newTestWasmState(t, newTestTendermintState(t, 14*24*time.Hour)),
+			expectedChainId:        "dst-1",
+			expectedTrustingPeriod: 14 * 24 * time.Hour,
+		},
+		{
+			name:  "invalid tendermint state",
+			value: newTestWasmState(t, newTestTendermintState(t, 0)),
+		},
+		{
+			name:  "data of other contract",
+			value: newTestWasmState(t, otherData),
+		},
+		{
+			name:  "no data",
+			value: newTestWasmState(t, nil),
+		},
+		{
+			name:  "invalid client state",
+			value: []byte{0xff},
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := newWasmClient("08-wasm-0", test.value)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+			if test.err {
+				return
+			}
+
+			if client.ChainId() != test.expectedChainId {
+				t.Fatalf("expected chain id %q, got %q", test.expectedChainId, client.ChainId())
+			}
+			if client.TrustingPeriod() != test.expectedTrustingPeriod {
+				t.Fatalf("expected trusting period %s, got %s", test.expectedTrustingPeriod, client.TrustingPeriod())
+			}
+			revisionNumber, revisionHeight := client.LatestHeight()
+			if revisionNumber != 1 || revisionHeight != 100 {
+				t.Fatalf("expected latest height 1-100, got %d-%d", revisionNumber, revisionHeight)
+			}
+		})
+	}
+}
+
+func TestClientType(t *testing.T) {
+	tests := []struct {
+		clientId string
+
+		expected string
+	}{
+		{clientId: "07-tendermint-0", expected: "07-tendermint"},
+		{clientId: "08-wasm-12", expected: "08-wasm"},
+		{clientId: "09-localhost", expected: "09-localhost"},
+		{clientId: "client", expected: "client"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.clientId, func(t *testing.T) {
+			result := clientType(test.clientId)
+			if result != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/rpc"
+	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	PACKET_TRACKING_POLLING = "polling"
+	PACKET_TRACKING_EVENT   = "event"
+
+	// subscription is considered broken if no new block is received within this time
+	SUBSCRIPTION_STALE_TIMEOUT = 1 * time.Minute
+	SUBSCRIPTION_RETRY_DELAY   = 5 * time.Second
+
+	// max number of buffered events per tracker, TxSearch is used for the rest
+	MAX_BUFFERED_EVENTS = 4096
+)
+
+type (
+	// chainId => packetSubscriber
+	packetSubscribers map[string]*packetSubscriber
+
+	// subscribe ibc packet events of a chain over websocket and dispatch them to trackers
+	packetSubscriber struct {
+		chainId string
+		rpc     *rpc.Client
+
+		mutex sync.Mutex
+		// trackers this chain is the source of (send_packet, acknowledge_packet, timeout_packet)
+		sources map[packetRoute]*IBCPacketTracker
+		// trackers this chain is the destination of (recv_packet)
+		destinations map[packetRoute]*IBCPacketTracker
+	}
+
+	// srcChannelId/srcPortId => dstChannelId/dstPortId
+	packetRoute string
+)
+
+func newPacketSubscriber(chainId string, rpc *rpc.Client) *packetSubscriber {
+	return &packetSubscriber{
+		chainId: chainId,
+		rpc:     rpc,
+
+		sources:      make(map[packetRoute]*IBCPacketTracker),
+		destinations: make(map[packetRoute]*IBCPacketTracker),
+	}
+}
+
+func newPacketRoute(srcChannelId, srcPortId, dstChannelId, dstPortId string) packetRoute {
+	return packetRoute(fmt.Sprintf("%s/%s=>%s/%s", srcChannelId, srcPortId, dstChannelId, dstPortId))
+}
+
+func (subscribers packetSubscribers) register(ibcPacketTracker *IBCPacketTracker) {
+	route := newPacketRoute(
+		ibcPacketTracker.Source.ChannelId, ibcPacketTracker.Source.PortId,
+		ibcPacketTracker.Destination.ChannelId, ibcPacketTracker.Destination.PortId,
+	)
+
+	src, srcOk := subscribers[ibcPacketTracker.Source.ChainId]
+	dst, dstOk := subscribers[ibcPacketTracker.Destination.ChainId]
+	if !srcOk || !dstOk {
+		return
+	}
+
+	// the buffer should be ready before the subscribers deliver events to the tracker
+	ibcPacketTracker.subscribe()
+
+	src.mutex.Lock()
+	src.sources[route] = ibcPacketTracker
+	src.mutex.Unlock()
+
+	dst.mutex.Lock()
+	dst.destinations[route] = ibcPacketTracker
+	dst.mutex.Unlock()
+}
+
+// unregister the stopped tracker, so its events are not dispatched anymore
+func (subscribers packetSubscribers) unregister(ibcPacketTracker *IBCPacketTracker) {
+	route := newPacketRoute(
+		ibcPacketTracker.Source.ChannelId, ibcPacketTracker.Source.PortId,
+		ibcPacketTracker.Destination.ChannelId, ibcPacketTracker.Destination.PortId,
+	)
+
+	if src, ok := subscribers[ibcPacketTracker.Source.ChainId]; ok {
+		src.mutex.Lock()
+		if src.sources[route] == ibcPacketTracker {
+			delete(src.sources, route)
+		}
+		src.mutex.Unlock()
+	}
+
+	if dst, ok := subscribers[ibcPacketTracker.Destination.ChainId]; ok {
+		dst.mutex.Lock()
+		if dst.destinations[route] == ibcPacketTracker {
+			delete(dst.destinations, route)
+		}
+		dst.mutex.Unlock()
+	}
+}
+
+func (s *packetSubscriber) run(ctx context.Context) {
+	for {
+		err := s.subscribe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		msg := fmt.Sprintf("packet subscription for %s interrupted, fall back to tx search: %s", s.chainId, err)
+		logger.Warn(msg)
+
+		// subscribe again through the next endpoint
+		s.rpc.Failover(err)
+
+		s.markGap()
+
+		select {
+		case <-time.After(SUBSCRIPTION_RETRY_DELAY):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *packetSubscriber) subscribe(ctx context.Context) error {
+	// each subscription owns a websocket connection, so it can be dropped on reconnect
+	client, err := s.rpc.Clone()
+	if err != nil {
+		return err
+	}
+	err = client.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := client.Terminate()
+		if err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heights, err := client.SubscribeNewBlockHeight(ctx)
+	if err != nil {
+		return err
+	}
+	sends, err := client.SubscribeIBCPackets(ctx, PACKET_STATUS_SEND.String())
+	if err != nil {
+		return err
+	}
+	recvs, err := client.SubscribeIBCPackets(ctx, PACKET_STATUS_RECV.String())
+	if err != nil {
+		return err
+	}
+	acks, err := client.SubscribeIBCPackets(ctx, PACKET_STATUS_ACK.String())
+	if err != nil {
+		return err
+	}
+	timeouts, err := client.SubscribeIBCPackets(ctx, PACKET_STATUS_TIMEOUT.String())
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("subscribed ibc packet events for %s", s.chainId)
+	logger.Info(msg)
+
+	stale := time.NewTimer(SUBSCRIPTION_STALE_TIMEOUT)
+	defer stale.Stop()
+
+	var latestHeight int64 = 0
+	for {
+		select {
+		case height, ok := <-heights:
+			if !ok {
+				return ctx.Err()
+			}
+
+			// blocks were skipped, events in them could be lost
+			if latestHeight != 0 && height > latestHeight+1 {
+				msg := fmt.Sprintf("missed blocks %d~%d of %s in subscription", latestHeight+1, height-1, s.chainId)
+				logger.Debug(msg)
+
+				s.markGap()
+			}
+			latestHeight = height
+
+			stale.Reset(SUBSCRIPTION_STALE_TIMEOUT)
+		case event, ok := <-sends:
+			if !ok {
+				return ctx.Err()
+			}
+			s.dispatch(event)
+		case event, ok := <-recvs:
+			if !ok {
+				return ctx.Err()
+			}
+			s.dispatch(event)
+		case event, ok := <-acks:
+			if !ok {
+				return ctx.Err()
+			}
+			s.dispatch(event)
+		case event, ok := <-timeouts:
+			if !ok {
+				return ctx.Err()
+			}
+			s.dispatch(event)
+		case <-stale.C:
+			msg := fmt.Sprintf("no new block of %s for %s", s.chainId, SUBSCRIPTION_STALE_TIMEOUT)
+			return errors.New(msg)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *packetSubscriber) dispatch(event rpc.IBCPacketEvent) {
+	route := newPacketRoute(event.SrcChannelId, event.SrcPortId, event.DstChannelId, event.DstPortId)
+
+	s.mutex.Lock()
+	ibcPacketTracker, ok := s.sources[route]
+	if event.PacketType == PACKET_STATUS_RECV.String() {
+		ibcPacketTracker, ok = s.destinations[route]
+	}
+	s.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	ibcPacketTracker.deliver(event)
+}
+
+func (s *packetSubscriber) markGap() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, ibcPacketTracker := range s.sources {
+		ibcPacketTracker.markGap()
+	}
+	for _, ibcPacketTracker := range s.destinations {
+		ibcPacketTracker.markGap()
+	}
+}
@@ -3,6 +3,8 @@ package app
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/dlvlabs/ibcmon/alert"
@@ -10,7 +12,8 @@ import (
 	"github.com/dlvlabs/ibcmon/client/rpc"
 	"github.com/dlvlabs/ibcmon/logger"
 	"github.com/pkg/errors"
-	"golang.org/x/sync/errgroup"
+
+	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
 )
 
 type PacketTypes int
@@ -19,6 +22,7 @@ const (
 	PACKET_STATUS_SEND PacketTypes = iota
 	PACKET_STATUS_RECV
 	PACKET_STATUS_ACK
+	PACKET_STATUS_TIMEOUT
 )
 
 func (ps PacketTypes) String() string {
@@ -29,30 +33,122 @@ func (ps PacketTypes) String() string {
 		return "recv_packet"
 	case PACKET_STATUS_ACK:
 		return "acknowledge_packet"
+	case PACKET_STATUS_TIMEOUT:
+		return "timeout_packet"
 	default:
 		return "unknown"
 	}
 }
 
+const (
+	// max number of in-flight packets per channel, the oldest one is dropped as missed
+	MAX_IN_FLIGHT_PACKETS = 10000
+	// number of latest relayed packets kept per channel
+	MAX_RELAYED_PACKETS = 100
+	// max number of sequence ranges searched per packet type, the closest ranges are merged over it
+	MAX_SEARCH_RANGES = 10
+)
+
 type (
 	IBCPacketTracker struct {
+		mutex sync.RWMutex
+
 		Updated time.Time
 
 		Health bool
 
-		PacketType PacketTypes
-		Sequence   uint64
-		timeout    Timeout
+		// next sequence to be observed in send_packet
+		Sequence uint64
+		// strategy which the first sequence is discovered with
+		SequenceSource string
+		// every sent packet waiting for recv_packet, acknowledge_packet or timeout_packet
+		InFlightPackets InFlightPackets
+		// latest relayed packets in acknowledged order
+		RelayedPackets []RelayedPacket
 
 		LatestSucceedPackets SucceedPackets
 		MissedCnt            uint64
 
+		// numbers of packets acknowledged, timed out on source and failed to be relayed since tracking started
+		RelayedCnt  uint64
+		TimedOutCnt uint64
+		FailedCnt   uint64
+		// number of packets received with an error acknowledgement, which are relayed but failed in the app
+		ErrorAckCnt uint64
+		// latencies of relayed packets per phase
+		Latencies LatencyHistograms
+		// relayers of the packets found from the signers of txs
+		Relayers RelayerStats
+
+		// set while tracking fails, the tracker is retried with backoff
+		Degraded *Degradation
+
 		Source      Chain
 		Destination Chain
+
+		// events delivered by packetSubscribers in PACKET_TRACKING_EVENT mode
+		events packetEvents
+
+		// copy of the tracker shared by snapshots until the tracker changes, see IBCPacketTracker.lock
+		published *IBCPacketTracker
+	}
+	// sequence => InFlightPacket
+	InFlightPackets map[uint64]*InFlightPacket
+	InFlightPacket  struct {
+		Sequence uint64
+		// packet type waiting for, PACKET_STATUS_RECV, PACKET_STATUS_ACK or PACKET_STATUS_TIMEOUT
+		PacketType PacketTypes
+		Timeout    Timeout
+
+		// block times of send_packet and recv_packet, or when they are observed if the block times are unknown
+		Sent     time.Time
+		Received time.Time
+		// block times of send_packet and recv_packet, zero if unknown, latencies are measured between them only
+		SentBlockTime     time.Time
+		ReceivedBlockTime time.Time
+		// signer of recv_packet
+		RecvRelayer string
+		// when the packet is found timed out on destination, its funds stay escrowed until timeout_packet on source
+		TimedOut time.Time
+	}
+	RelayedPacket struct {
+		Sequence uint64
+		// from send_packet to acknowledge_packet, 0 if either block time is unknown
+		Latency time.Duration
+		// from send_packet to recv_packet, and from recv_packet to acknowledge_packet, 0 if either block time is unknown
+		RecvLatency time.Duration
+		AckLatency  time.Duration
+
+		// signers of recv_packet and acknowledge_packet
+		RecvRelayer string
+		AckRelayer  string
+	}
+	packetEvents struct {
+		mutex sync.Mutex
+
+		// set by packetSubscribers.register before the tracker gets routed
+		subscribed bool
+		// PacketType/Sequence => event
+		buffer map[packetEventKey]rpc.IBCPacketEvent
+
+		// gap is not filled yet if marked != filled
+		marked uint64
+		filled uint64
+	}
+	// sequences in [From, To], To 0 means no upper bound
+	sequenceRange struct {
+		From uint64
+		To   uint64
+	}
+	packetEventKey struct {
+		packetType string
+		sequence   uint64
 	}
 	Timeout struct {
-		timeoutHeight    uint64
-		timeoutTimestamp int64
+		// zero if the packet times out only with the timestamp
+		Height clientTypes.Height
+		// unix nano, zero if the packet times out only with the height
+		Timestamp int64
 	}
 	Chain struct {
 		rpc       *rpc.Client
@@ -60,6 +156,13 @@ type (
 		ChainId   string
 		ChannelId string
 		PortId    string
+		// see Channel.V2
+		V2 bool
+	}
+	// tracker started by app.syncIBCPacketTrackers, stopped when its channel is not found anymore
+	runningTracker struct {
+		ibcPacketTracker *IBCPacketTracker
+		cancel           context.CancelFunc
 	}
 	// PacketType => SucceedPacket
 	SucceedPackets map[string]SucceedPacket
@@ -67,11 +170,14 @@ type (
 		Hash     string
 		Sequence uint64
 		Data     string
+		// error of the acknowledgement written by recv_packet, empty if it's successful
+		AckError string
 	}
 )
 
 func NewIBCPacketTracker(
 	sequence uint64,
+	v2 bool,
 
 	srcRPC *rpc.Client, srcGRPC *grpc.Client,
 	srcChainId, srcChannelId, srcPortId string,
@@ -84,22 +190,23 @@ func NewIBCPacketTracker(
 
 		Health: true,
 
-		PacketType: PACKET_STATUS_SEND,
-		Sequence:   sequence,
-		timeout: Timeout{
-			timeoutHeight:    0,
-			timeoutTimestamp: 0,
-		},
+		Sequence:        sequence,
+		InFlightPackets: make(InFlightPackets),
+		RelayedPackets:  make([]RelayedPacket, 0, MAX_RELAYED_PACKETS),
 
 		LatestSucceedPackets: make(SucceedPackets),
 		MissedCnt:            0,
 
+		Latencies: make(LatencyHistograms),
+		Relayers:  make(RelayerStats),
+
 		Source: Chain{
 			rpc:       srcRPC,
 			grpc:      srcGRPC,
 			ChainId:   srcChainId,
 			ChannelId: srcChannelId,
 			PortId:    srcPortId,
+			V2:        v2,
 		},
 
 		Destination: Chain{
@@ -108,16 +215,171 @@ func NewIBCPacketTracker(
 			ChainId:   dstChainId,
 			ChannelId: dstChannelId,
 			PortId:    dstPortId,
+			V2:        v2,
 		},
 	}
 }
 
-func (ibcPacketTracker *IBCPacketTracker) GetPacketStatus() string {
-	return ibcPacketTracker.PacketType.String()
+func (ibcPacketTracker *IBCPacketTracker) subscribe() {
+	events := &ibcPacketTracker.events
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	events.subscribed = true
+	events.buffer = make(map[packetEventKey]rpc.IBCPacketEvent)
+
+	// events before the subscription should be searched
+	events.marked = 1
+}
+
+func (ibcPacketTracker *IBCPacketTracker) isSubscribed() bool {
+	events := &ibcPacketTracker.events
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	return events.subscribed
+}
+
+func (ibcPacketTracker *IBCPacketTracker) deliver(event rpc.IBCPacketEvent) {
+	events := &ibcPacketTracker.events
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	if !events.subscribed {
+		return
+	}
+	if len(events.buffer) >= MAX_BUFFERED_EVENTS {
+		events.marked++
+		return
+	}
+
+	events.buffer[packetEventKey{event.PacketType, event.Sequence}] = event
+}
+
+func (ibcPacketTracker *IBCPacketTracker) markGap() {
+	events := &ibcPacketTracker.events
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	events.marked++
+}
+
+// return the gap marker, and whether there's unfilled gap
+func (ibcPacketTracker *IBCPacketTracker) gap() (uint64, bool) {
+	events := &ibcPacketTracker.events
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	return events.marked, events.marked != events.filled
+}
+
+func (ibcPacketTracker *IBCPacketTracker) fillGap(marked uint64) {
+	events := &ibcPacketTracker.events
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	events.filled = marked
+}
+
+// take all of buffered events of the packet type
+func (ibcPacketTracker *IBCPacketTracker) takeEvents(packetType PacketTypes) []rpc.IBCPacketEvent {
+	events := &ibcPacketTracker.events
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	var result []rpc.IBCPacketEvent
+	for key, event := range events.buffer {
+		if key.packetType != packetType.String() {
+			continue
+		}
+
+		result = append(result, event)
+		delete(events.buffer, key)
+	}
+
+	return result
+}
+
+func (ibcPacketTracker *IBCPacketTracker) state() TrackerState {
+	ibcPacketTracker.mutex.RLock()
+	defer ibcPacketTracker.mutex.RUnlock()
+
+	return TrackerState{
+		Health: ibcPacketTracker.Health,
+
+		Sequence:        ibcPacketTracker.Sequence,
+		SequenceSource:  ibcPacketTracker.SequenceSource,
+		InFlightPackets: ibcPacketTracker.GetInFlightPackets(),
+		RelayedPackets:  ibcPacketTracker.GetRelayedPackets(),
+
+		LatestSucceedPackets: ibcPacketTracker.GetLatestSucceedPackets(),
+		MissedCnt:            ibcPacketTracker.MissedCnt,
+
+		RelayedCnt:  ibcPacketTracker.RelayedCnt,
+		TimedOutCnt: ibcPacketTracker.TimedOutCnt,
+		FailedCnt:   ibcPacketTracker.FailedCnt,
+		ErrorAckCnt: ibcPacketTracker.ErrorAckCnt,
+		Latencies:   ibcPacketTracker.Latencies.copy(),
+		Relayers:    ibcPacketTracker.Relayers.copy(),
+	}
+}
+
+func (ibcPacketTracker *IBCPacketTracker) restore(state TrackerState) {
+	ibcPacketTracker.lock()
+	defer ibcPacketTracker.mutex.Unlock()
+
+	ibcPacketTracker.Health = state.Health
+
+	ibcPacketTracker.Sequence = state.Sequence
+	ibcPacketTracker.SequenceSource = state.SequenceSource
+	for _, inFlightPacket := range state.InFlightPackets {
+		ibcPacketTracker.InFlightPackets[inFlightPacket.Sequence] = &inFlightPacket
+	}
+	ibcPacketTracker.RelayedPackets = append(ibcPacketTracker.RelayedPackets, state.RelayedPackets...)
+
+	for packetType, succeedPacket := range state.LatestSucceedPackets {
+		ibcPacketTracker.LatestSucceedPackets[packetType] = succeedPacket
+	}
+	ibcPacketTracker.MissedCnt = state.MissedCnt
+
+	ibcPacketTracker.RelayedCnt = state.RelayedCnt
+	ibcPacketTracker.TimedOutCnt = state.TimedOutCnt
+	ibcPacketTracker.FailedCnt = state.FailedCnt
+	ibcPacketTracker.ErrorAckCnt = state.ErrorAckCnt
+	for phase, histogram := range state.Latencies {
+		if len(histogram.Buckets) == len(LATENCY_BUCKETS) {
+			ibcPacketTracker.Latencies[phase] = histogram
+		}
+	}
+	for key, relayer := range state.Relayers {
+		ibcPacketTracker.Relayers[key] = relayer
+	}
+}
+
+// GetInFlightPackets returns copies of in-flight packets sorted by sequence,
+// the caller should hold the lock unless the tracker is a copy in Store.Snapshot
+func (ibcPacketTracker *IBCPacketTracker) GetInFlightPackets() []InFlightPacket {
+	inFlightPackets := make([]InFlightPacket, 0, len(ibcPacketTracker.InFlightPackets))
+	for _, inFlightPacket := range ibcPacketTracker.InFlightPackets {
+		inFlightPackets = append(inFlightPackets, *inFlightPacket)
+	}
+	sort.Slice(inFlightPackets, func(i, j int) bool {
+		return inFlightPackets[i].Sequence < inFlightPackets[j].Sequence
+	})
+
+	return inFlightPackets
 }
-func (ibcPacketTracker *IBCPacketTracker) GetSequence() uint64 {
-	return ibcPacketTracker.Sequence
+func (ibcPacketTracker *IBCPacketTracker) GetRelayedPackets() []RelayedPacket {
+	return append([]RelayedPacket{}, ibcPacketTracker.RelayedPackets...)
 }
+func (ibcPacketTracker *IBCPacketTracker) GetLatestSucceedPackets() SucceedPackets {
+	latestSucceedPackets := make(SucceedPackets)
+	for packetType, succeedPacket := range ibcPacketTracker.LatestSucceedPackets {
+		latestSucceedPackets[packetType] = succeedPacket
+	}
+	return latestSucceedPackets
+}
+
 func (ibcPacketTracker *IBCPacketTracker) GetSrcInfo() (string, string, string) {
 	return ibcPacketTracker.Source.ChainId, ibcPacketTracker.Source.ChannelId, ibcPacketTracker.Source.PortId
 }
@@ -125,197 +387,734 @@ func (ibcPacketTracker *IBCPacketTracker) GetDstInfo() (string, string, string)
 	return ibcPacketTracker.Destination.ChainId, ibcPacketTracker.Destination.ChannelId, ibcPacketTracker.Destination.PortId
 }
 
+func (ibcPacketTracker *IBCPacketTracker) IsV2() bool {
+	return ibcPacketTracker.Source.V2
+}
+
 func (ibcPacketTracker *IBCPacketTracker) String() string {
-	return fmt.Sprintf(
-		"%s(%d) for %s(%s/%s) => %s(%s/%s)",
-		ibcPacketTracker.PacketType.String(), ibcPacketTracker.Sequence,
-		ibcPacketTracker.Source.ChainId, ibcPacketTracker.Source.ChannelId, ibcPacketTracker.Source.PortId,
-		ibcPacketTracker.Destination.ChainId, ibcPacketTracker.Destination.ChannelId, ibcPacketTracker.Destination.PortId,
-	)
+	return fmt.Sprintf("%s => %s", ibcPacketTracker.Source.String(), ibcPacketTracker.Destination.String())
 }
 
-func (ibcPacketTracker *IBCPacketTracker) transitStatus(timeoutHeight uint64, timeoutTimestamp int64) {
-	ibcPacketTracker.timeout.timeoutHeight = timeoutHeight
-	ibcPacketTracker.timeout.timeoutTimestamp = timeoutTimestamp
+func (ibcPacketTracker *IBCPacketTracker) packetString(packetType PacketTypes, sequence uint64) string {
+	return fmt.Sprintf("%s(%d) for %s", packetType.String(), sequence, ibcPacketTracker.String())
+}
 
-	if ibcPacketTracker.PacketType == PACKET_STATUS_ACK {
-		ibcPacketTracker.Health = true
-		ibcPacketTracker.Sequence++
-		ibcPacketTracker.MissedCnt = 0
+// chain which the txs of the packet type are sent to
+func (ibcPacketTracker *IBCPacketTracker) relayedOn(packetType PacketTypes) string {
+	if packetType == PACKET_STATUS_RECV {
+		return ibcPacketTracker.Destination.ChainId
 	}
-	ibcPacketTracker.PacketType = (ibcPacketTracker.PacketType + 1) % 3
+	return ibcPacketTracker.Source.ChainId
+}
 
-	ibcPacketTracker.Updated = time.Now().UTC()
+// return ranges of consecutive sequences of in-flight packets waiting for the packet type,
+// so the packets already handled are not searched again while an older packet is stuck
+func (ibcPacketTracker *IBCPacketTracker) waitingRanges(packetType PacketTypes) []sequenceRange {
+	var sequences []uint64
+	for sequence, inFlightPacket := range ibcPacketTracker.InFlightPackets {
+		if inFlightPacket.PacketType == packetType {
+			sequences = append(sequences, sequence)
+		}
+	}
+	if len(sequences) == 0 {
+		return nil
+	}
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+	var ranges []sequenceRange
+	for _, sequence := range sequences {
+		if len(ranges) != 0 && ranges[len(ranges)-1].To+1 == sequence {
+			ranges[len(ranges)-1].To = sequence
+			continue
+		}
+		ranges = append(ranges, sequenceRange{From: sequence, To: sequence})
+	}
+
+	return mergeRanges(ranges, MAX_SEARCH_RANGES)
 }
 
-func (ibcPacketTracker *IBCPacketTracker) isTimeout(ctx context.Context) (bool, error) {
-	if ibcPacketTracker.PacketType != PACKET_STATUS_RECV {
-		return false, nil
+// merge the ranges separated by the smallest gaps until there are at most max ranges
+func mergeRanges(ranges []sequenceRange, max int) []sequenceRange {
+	if len(ranges) <= max {
+		return ranges
 	}
 
-	if ibcPacketTracker.timeout.timeoutHeight != 0 {
-		height, err := ibcPacketTracker.Destination.rpc.GetLatestBlockHeight(ctx)
-		if err != nil {
-			return false, err
+	// gaps[i] is between ranges[i] and ranges[i+1]
+	gaps := make([]int, len(ranges)-1)
+	for i := range gaps {
+		gaps[i] = i
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return ranges[gaps[i]+1].From-ranges[gaps[i]].To < ranges[gaps[j]+1].From-ranges[gaps[j]].To
+	})
+
+	merged := make(map[int]bool)
+	for _, gap := range gaps[:len(ranges)-max] {
+		merged[gap] = true
+	}
+
+	result := []sequenceRange{ranges[0]}
+	for i := 1; i < len(ranges); i++ {
+		if merged[i-1] {
+			result[len(result)-1].To = ranges[i].To
+			continue
 		}
+		result = append(result, ranges[i])
+	}
+	return result
+}
+
+// same as ibc-go, the packet is timed out if the destination reaches either of the timeout height or timestamp,
+// heights are compared with revision numbers, so a packet doesn't time out by the height of an older revision
+func (ibcPacketTracker *IBCPacketTracker) isTimeout(inFlightPacket *InFlightPacket, latestHeight clientTypes.Height, latestTime time.Time) bool {
+	timeout := inFlightPacket.Timeout
+
+	if !timeout.Height.IsZero() {
+		msg := fmt.Sprintf("timeout height: %s <= current height: %s", timeout.Height, latestHeight)
+		logger.Debug(msg)
+		if latestHeight.GTE(timeout.Height) {
+			return true
+		}
+	}
 
-		msg := fmt.Sprintf("timeoutHeight: %d <= current height: %d", ibcPacketTracker.timeout.timeoutHeight, height)
+	if timeout.Timestamp != 0 {
+		msg := fmt.Sprintf("timeout timestamp: %d <= current block time: %d", timeout.Timestamp, latestTime.UnixNano())
 		logger.Debug(msg)
-		return ibcPacketTracker.timeout.timeoutHeight <= uint64(height), nil
+		if latestTime.UnixNano() >= timeout.Timestamp {
+			return true
+		}
 	}
 
-	msg := fmt.Sprintf("timeout timestamp: %d <= now: %d", ibcPacketTracker.timeout.timeoutTimestamp, time.Now().UnixNano())
-	logger.Debug(msg)
-	return ibcPacketTracker.timeout.timeoutTimestamp <= time.Now().UnixNano(), nil
+	return false
+}
+
+// timeout_packet can be observed before the packet is found timed out by app.stepIBCPacketTracker
+func (inFlightPacket *InFlightPacket) isWaitingFor(packetType PacketTypes) bool {
+	if packetType == PACKET_STATUS_TIMEOUT && inFlightPacket.PacketType == PACKET_STATUS_RECV {
+		return true
+	}
+	return inFlightPacket.PacketType == packetType
 }
 
-func (app *App) trackIBCPacket(ctx context.Context) error {
-	ctx, cancel := context.WithCancelCause(ctx)
-	defer cancel(errors.New("terminate ibc packet tracker"))
+// return sequences of the packets timed out on destination longer than warningTime, but not timed out on source,
+// the caller should hold the lock
+func (ibcPacketTracker *IBCPacketTracker) unconfirmedTimeouts(warningTime time.Duration) []uint64 {
+	var sequences []uint64
+	for _, inFlightPacket := range ibcPacketTracker.GetInFlightPackets() {
+		if inFlightPacket.PacketType != PACKET_STATUS_TIMEOUT || time.Since(inFlightPacket.TimedOut) < warningTime {
+			continue
+		}
+		sequences = append(sequences, inFlightPacket.Sequence)
+	}
+	return sequences
+}
 
+// start the trackers of new channels and stop the trackers of the channels not found anymore,
+// the trackers of unchanged channels keep running with their progress
+func (app *App) syncIBCPacketTrackers(ctx context.Context) error {
 	err := app.connectGRPCs()
 	if err != nil {
 		return err
 	}
+	defer func() {
+		err := app.terminateGRPCs()
+		if err != nil {
+			logger.Error(err)
+		}
+	}()
 
-	g, ctx := errgroup.WithContext(ctx)
+	discoverCtx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	defer cancel()
 
-	for chainId, clients := range app.Store.IBCInfo {
-		for _, client := range clients {
-			for _, channels := range client.Connections {
-				for channelId, channel := range channels {
-					grpcClient := app.grpcs[chainId]
-					nextSequence, err := grpcClient.GetNextSequenceSend(ctx, channelId, channel.PortId)
-					if err != nil {
-						if errors.Is(errors.Cause(err), grpc.UNIMPLMENTED) {
-							msg := fmt.Sprintf(
-								"not support `NextSequenceSend` query, skip tracking IBC packet for %s(%s/%s) => %s(%s/%s)",
-								chainId, channelId, channel.PortId,
-								client.ChainId, channel.Counterparty.ChannelId, channel.Counterparty.PortId,
-							)
-							logger.Info(msg)
+	app.trackersMutex.Lock()
+	defer app.trackersMutex.Unlock()
 
-							continue
-						}
+	ibcInfo := app.ibcInfo()
 
-						return err
-					}
+	// subscribers run until the app stops, even if every tracker of the chain is stopped
+	if app.cfg.General.PacketTrackingMode == PACKET_TRACKING_EVENT {
+		for chainId := range ibcInfo {
+			if _, ok := app.subscribers[chainId]; ok {
+				continue
+			}
 
-					ibcPacketTracker := NewIBCPacketTracker(
-						nextSequence,
+			subscriber := newPacketSubscriber(chainId, app.getRPC(chainId))
+			app.subscribers[chainId] = subscriber
+			go subscriber.run(ctx)
+		}
+	}
 
-						app.rpcs[chainId], app.grpcs[chainId],
-						chainId, channelId, channel.PortId,
+	found := make(map[string]bool)
+	for chainId, clients := range ibcInfo {
+		for clientId, client := range clients {
+			if !app.isMonitored(client) {
+				continue
+			}
 
-						app.rpcs[client.ChainId], app.grpcs[client.ChainId],
-						client.ChainId, channel.Counterparty.ChannelId, channel.Counterparty.PortId,
-					)
+			for _, path := range client.Paths(clientId) {
+				channelId, channel := path.ChannelId, path.Channel
+				// trackers of closed channels are stopped
+				if !app.canRelay(channel) {
+					continue
+				}
 
-					channel.IBCPacketTracker = ibcPacketTracker
+				key := trackerKey(chainId, channelId, channel.PortId)
+				found[key] = true
+				if _, ok := app.trackers[key]; ok {
+					continue
+				}
 
-					g.Go(func() error {
-						ticker := time.NewTicker(app.cfg.General.PacketTrackingInterval)
-						defer ticker.Stop()
+				ibcPacketTracker := NewIBCPacketTracker(
+					0,
+					channel.V2,
 
-						for {
-							select {
-							case <-ticker.C:
-								missed, err := ibcPacketTracker.track(ctx)
-								if err != nil {
-									err := errors.Wrapf(err, "track ibc packet stopped: %s", ibcPacketTracker.String())
-									logger.Error(err)
+					app.getRPC(chainId), app.getGRPC(chainId),
+					chainId, channelId, channel.PortId,
 
-									// All ibcPacketTracker should be stopped
-									cancel(errors.New("terminate ibc packet tracker"))
+					app.getRPC(client.ChainId), app.getGRPC(client.ChainId),
+					client.ChainId, channel.Counterparty.ChannelId, channel.Counterparty.PortId,
+				)
 
-									return err
-								}
+				// resume from the persisted tracker instead of the current sequence
+				if trackerState, ok := app.restored[key]; ok {
+					ibcPacketTracker.restore(trackerState)
 
-								if missed {
-									ibcPacketTracker.PacketType = PACKET_STATUS_SEND
-									ibcPacketTracker.Sequence++
+					app.runIBCPacketTracker(ctx, key, channel, ibcPacketTracker, true)
 
-									ibcPacketTracker.MissedCnt++
-									if ibcPacketTracker.MissedCnt >= app.cfg.Rule.ConsecutiveMissedPackets {
-										ibcPacketTracker.Health = false
-									}
+					msg := fmt.Sprintf("resume tracking ibc packet from %d: %s", ibcPacketTracker.Sequence, ibcPacketTracker.String())
+					logger.Info(msg)
 
-									ibcPacketTracker.Updated = time.Now().UTC()
+					continue
+				}
 
-									msg := fmt.Sprintf("missed %d ibc tx: %s", ibcPacketTracker.MissedCnt, ibcPacketTracker.String())
-									logger.Warn(msg)
+				// the other trackers are started even if the sequence of this channel is not discovered
+				err := ibcPacketTracker.discoverSequence(discoverCtx)
+				if err != nil {
+					ibcPacketTracker.degrade(err)
 
-									// TODO: consider remove this line
-									alert.SendTg(msg)
-								}
+					logger.Error(errors.Wrapf(err, "failed to discover sequence, retry later: %s", ibcPacketTracker.String()))
+				}
 
-							case <-ctx.Done():
-								return context.Cause(ctx)
-							}
-						}
-					})
+				app.runIBCPacketTracker(ctx, key, channel, ibcPacketTracker, err == nil)
+				if err != nil {
+					continue
+				}
 
-					msg := fmt.Sprintf("start tracking ibc packet: %s", ibcPacketTracker.String())
-					logger.Info(msg)
+				msg := fmt.Sprintf(
+					"start tracking ibc packet from %d(%s): %s",
+					ibcPacketTracker.Sequence, ibcPacketTracker.SequenceSource, ibcPacketTracker.String(),
+				)
+				logger.Info(msg)
+			}
+		}
+	}
+
+	// the persisted states are stale after the first sync, the channels found later start from the current sequence
+	app.restored = nil
+
+	for key, tracker := range app.trackers {
+		if found[key] {
+			continue
+		}
+
+		tracker.cancel()
+		app.subscribers.unregister(tracker.ibcPacketTracker)
+		delete(app.trackers, key)
+
+		msg := fmt.Sprintf("stop tracking ibc packet, the channel is not found anymore: %s", tracker.ibcPacketTracker.String())
+		logger.Info(msg)
+	}
+
+	return nil
+}
+
+// failure of a tracker doesn't stop the others, it's retried with backoff,
+// app.trackersMutex should be held
+func (app *App) runIBCPacketTracker(
+	ctx context.Context,
+	key string,
+	channel *Channel,
+	ibcPacketTracker *IBCPacketTracker,
+	discovered bool,
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	app.trackers[key] = &runningTracker{
+		ibcPacketTracker: ibcPacketTracker,
+		cancel:           cancel,
+	}
+
+	app.subscribers.register(ibcPacketTracker)
+
+	app.updateStore(func() { channel.IBCPacketTracker = ibcPacketTracker })
+
+	go func() {
+		name := fmt.Sprintf("track ibc packet %s", ibcPacketTracker.String())
+		supervise(ctx, name, app.cfg.General.PacketTrackingInterval, func(ctx context.Context) error {
+			if !discovered {
+				err := app.discoverSequence(ctx, ibcPacketTracker)
+				if err != nil {
+					return err
 				}
+				discovered = true
 			}
+
+			return app.stepIBCPacketTracker(ctx, ibcPacketTracker)
+		}, func(err error) {
+			ibcPacketTracker.degrade(err)
+			app.trackersChanged.Store(true)
+		})
+	}()
+}
+
+// discover the sequence again after the grpcs of syncIBCPacketTrackers are terminated
+func (app *App) discoverSequence(ctx context.Context, ibcPacketTracker *IBCPacketTracker) error {
+	err := app.connectGRPCs()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := app.terminateGRPCs()
+		if err != nil {
+			logger.Error(err)
 		}
+	}()
+
+	err = ibcPacketTracker.discoverSequence(ctx)
+	if err != nil {
+		return err
 	}
 
-	err = app.terminateGRPCs()
+	msg := fmt.Sprintf(
+		"start tracking ibc packet from %d(%s): %s",
+		ibcPacketTracker.Sequence, ibcPacketTracker.SequenceSource, ibcPacketTracker.String(),
+	)
+	logger.Info(msg)
+
+	return nil
+}
+
+// lock the tracker to change it, the copy published before is replaced on the next publish
+func (ibcPacketTracker *IBCPacketTracker) lock() {
+	ibcPacketTracker.mutex.Lock()
+	ibcPacketTracker.published = nil
+}
+
+func (ibcPacketTracker *IBCPacketTracker) degrade(err error) {
+	ibcPacketTracker.lock()
+	defer ibcPacketTracker.mutex.Unlock()
+
+	ibcPacketTracker.Degraded = ibcPacketTracker.Degraded.update(err)
+}
+
+func (app *App) stepIBCPacketTracker(ctx context.Context, ibcPacketTracker *IBCPacketTracker) error {
+	missedPackets, err := ibcPacketTracker.track(ctx)
 	if err != nil {
-		logger.Error(err)
 		return err
 	}
 
-	return g.Wait()
+	for _, missedPacket := range missedPackets {
+		ibcPacketTracker.lock()
+		ibcPacketTracker.MissedCnt++
+		if ibcPacketTracker.MissedCnt >= app.cfg.Rule.ConsecutiveMissedPackets {
+			ibcPacketTracker.Health = false
+		}
+		missedCnt := ibcPacketTracker.MissedCnt
+		ibcPacketTracker.mutex.Unlock()
+
+		msg := fmt.Sprintf("missed %d ibc tx: %s", missedCnt, ibcPacketTracker.packetString(missedPacket.PacketType, missedPacket.Sequence))
+		logger.Warn(msg)
+	}
+
+	ibcPacketTracker.mutex.RLock()
+	health, missedCnt := ibcPacketTracker.Health, ibcPacketTracker.MissedCnt
+	ibcPacketTracker.mutex.RUnlock()
+
+	alertKey := alert.Key{
+		ChainId:             ibcPacketTracker.Source.ChainId,
+		CounterpartyChainId: ibcPacketTracker.Destination.ChainId,
+		ChannelId:           ibcPacketTracker.Source.ChannelId,
+		PortId:              ibcPacketTracker.Source.PortId,
+
+		Condition: ALERT_MISSED_PACKETS,
+	}
+	if !health {
+		msg := fmt.Sprintf("missed %d consecutive ibc tx: %s", missedCnt, ibcPacketTracker.String())
+		alert.Fire(alertKey, alert.WARNING, msg)
+	} else {
+		msg := fmt.Sprintf("ibc packet relayed again: %s", ibcPacketTracker.String())
+		alert.Resolve(alertKey, msg)
+	}
+
+	ibcPacketTracker.mutex.RLock()
+	unconfirmed := ibcPacketTracker.unconfirmedTimeouts(app.cfg.Rule.TimeoutPacketWarningTime)
+	ibcPacketTracker.mutex.RUnlock()
+
+	alertKey.Condition = ALERT_UNCONFIRMED_TIMEOUTS
+	if len(unconfirmed) > 0 {
+		msg := fmt.Sprintf(
+			"%d timed out packets are not timed out on source for %s, funds stay escrowed: %s, sequences: %v",
+			len(unconfirmed), app.cfg.Rule.TimeoutPacketWarningTime, ibcPacketTracker.String(), unconfirmed,
+		)
+		logger.Warn(msg)
+		alert.Fire(alertKey, alert.WARNING, msg)
+	} else {
+		msg := fmt.Sprintf("timed out packets are timed out on source: %s", ibcPacketTracker.String())
+		alert.Resolve(alertKey, msg)
+	}
+
+	return nil
 }
 
-// if packet is missed return true
-func (ibcPacketTracker *IBCPacketTracker) track(ctx context.Context) (bool, error) {
-	rpc := ibcPacketTracker.Source.rpc
-	if ibcPacketTracker.PacketType == PACKET_STATUS_RECV {
-		rpc = ibcPacketTracker.Destination.rpc
+// search packets from subscribed events first, TxSearch is used only for polling or filling gaps
+func (ibcPacketTracker *IBCPacketTracker) search(
+	ctx context.Context,
+	packetType PacketTypes,
+	ranges []sequenceRange,
+	gap bool,
+) ([]rpc.IBCPacketEvent, error) {
+	rpcClient := ibcPacketTracker.Source.rpc
+	if packetType == PACKET_STATUS_RECV {
+		rpcClient = ibcPacketTracker.Destination.rpc
+	}
+
+	subscribed := ibcPacketTracker.isSubscribed()
+
+	var events []rpc.IBCPacketEvent
+	if subscribed {
+		events = ibcPacketTracker.takeEvents(packetType)
 	}
-	code, hash, data, timeoutHeight, timeoutTimestamp, isFound, err := rpc.SearchIBCPacket(ctx, ibcPacketTracker, 0)
+
+	if !subscribed || gap {
+		var searched []rpc.IBCPacketEvent
+		for _, sequences := range ranges {
+			result, err := rpcClient.SearchIBCPackets(ctx, ibcPacketTracker, packetType.String(), sequences.From, sequences.To)
+			if err != nil {
+				return nil, err
+			}
+			searched = append(searched, result...)
+		}
+		events = append(searched, events...)
+	}
+
+	// drop the events of packets already handled, so block times are fetched only for the rest
+	ibcPacketTracker.mutex.RLock()
+	events = ibcPacketTracker.applicable(packetType, events)
+	ibcPacketTracker.mutex.RUnlock()
+
+	// latencies are measured between block times, timeout_packet isn't measured
+	if packetType != PACKET_STATUS_TIMEOUT {
+		rpcClient.SetBlockTimes(ctx, events)
+	}
+
+	return events, nil
+}
+
+// observe every packet of the channel, return packets missed in this round
+func (ibcPacketTracker *IBCPacketTracker) track(ctx context.Context) ([]InFlightPacket, error) {
+	var missedPackets []InFlightPacket
+
+	marked, gap := ibcPacketTracker.gap()
+	subscribed := ibcPacketTracker.isSubscribed()
+
+	// send_packet on source: every new packet gets in-flight
+	sendRanges := []sequenceRange{{From: ibcPacketTracker.Sequence}}
+	events, err := ibcPacketTracker.search(ctx, PACKET_STATUS_SEND, sendRanges, gap)
 	if err != nil {
-		return false, err
-	} else if !isFound {
-		// timeout
-		timeout, err := ibcPacketTracker.isTimeout(ctx)
+		return nil, err
+	}
+	ibcPacketTracker.lock()
+	missedPackets = append(missedPackets, ibcPacketTracker.applyEvents(PACKET_STATUS_SEND, events)...)
+	recvRanges := ibcPacketTracker.waitingRanges(PACKET_STATUS_RECV)
+	ibcPacketTracker.mutex.Unlock()
+
+	// recv_packet on destination, buffered events are always drained
+	if len(recvRanges) != 0 || subscribed {
+		events, err := ibcPacketTracker.search(ctx, PACKET_STATUS_RECV, recvRanges, gap && len(recvRanges) != 0)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-		if timeout {
-			msg := fmt.Sprintf("timeout ibc tx: %s", ibcPacketTracker.String())
-			logger.Debug(msg)
+		ibcPacketTracker.lock()
+		missedPackets = append(missedPackets, ibcPacketTracker.applyEvents(PACKET_STATUS_RECV, events)...)
+		ibcPacketTracker.mutex.Unlock()
+	}
 
-			return true, nil
+	// acknowledge_packet on source
+	ibcPacketTracker.mutex.RLock()
+	ackRanges := ibcPacketTracker.waitingRanges(PACKET_STATUS_ACK)
+	ibcPacketTracker.mutex.RUnlock()
+	if len(ackRanges) != 0 || subscribed {
+		events, err := ibcPacketTracker.search(ctx, PACKET_STATUS_ACK, ackRanges, gap && len(ackRanges) != 0)
+		if err != nil {
+			return nil, err
 		}
+		ibcPacketTracker.lock()
+		missedPackets = append(missedPackets, ibcPacketTracker.applyEvents(PACKET_STATUS_ACK, events)...)
+		ibcPacketTracker.mutex.Unlock()
+	}
 
-		// not found
-		msg := fmt.Sprintf("no ibc tx: %s", ibcPacketTracker.String())
-		logger.Debug(msg)
+	// timeout of packets still waiting for recv_packet
+	timeouts, err := ibcPacketTracker.timeouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	missedPackets = append(missedPackets, timeouts...)
 
-		return false, nil
+	// timeout_packet on source, which refunds the escrowed funds of timed out packets
+	ibcPacketTracker.mutex.RLock()
+	timeoutRanges := ibcPacketTracker.waitingRanges(PACKET_STATUS_TIMEOUT)
+	ibcPacketTracker.mutex.RUnlock()
+	if len(timeoutRanges) != 0 || subscribed {
+		events, err := ibcPacketTracker.search(ctx, PACKET_STATUS_TIMEOUT, timeoutRanges, gap && len(timeoutRanges) != 0)
+		if err != nil {
+			return nil, err
+		}
+		ibcPacketTracker.lock()
+		missedPackets = append(missedPackets, ibcPacketTracker.applyEvents(PACKET_STATUS_TIMEOUT, events)...)
+		ibcPacketTracker.mutex.Unlock()
 	}
-	if code != 0 {
-		msg := fmt.Sprintf("ibc tx not successed: %s", ibcPacketTracker.String())
+
+	if gap {
+		msg := fmt.Sprintf("gap filled: %s", ibcPacketTracker.String())
 		logger.Debug(msg)
 
-		return true, nil
+		ibcPacketTracker.fillGap(marked)
 	}
 
-	msg := fmt.Sprintf("ibc packet succeed: %s", ibcPacketTracker.String())
-	logger.Info(msg)
+	ibcPacketTracker.lock()
+	ibcPacketTracker.Updated = time.Now().UTC()
+	ibcPacketTracker.mutex.Unlock()
+
+	return missedPackets, nil
+}
+
+// return the events which applyEvents doesn't ignore, the caller should hold the lock
+func (ibcPacketTracker *IBCPacketTracker) applicable(packetType PacketTypes, events []rpc.IBCPacketEvent) []rpc.IBCPacketEvent {
+	var result []rpc.IBCPacketEvent
+	for _, event := range events {
+		if packetType == PACKET_STATUS_SEND {
+			if event.Code == 0 && event.Sequence >= ibcPacketTracker.Sequence {
+				result = append(result, event)
+			}
+			continue
+		}
+
+		inFlightPacket, ok := ibcPacketTracker.InFlightPackets[event.Sequence]
+		if ok && inFlightPacket.isWaitingFor(packetType) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
 
-	ibcPacketTracker.LatestSucceedPackets[ibcPacketTracker.PacketType.String()] = SucceedPacket{
-		Hash:     hash,
-		Sequence: ibcPacketTracker.Sequence,
-		Data:     data,
+// apply events to in-flight packets, the caller should hold the lock
+func (ibcPacketTracker *IBCPacketTracker) applyEvents(packetType PacketTypes, events []rpc.IBCPacketEvent) []InFlightPacket {
+	var missedPackets []InFlightPacket
+
+	// a failed tx is ignored if another tx relayed the same packet
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Code == 0 && events[j].Code != 0
+	})
+
+	now := time.Now().UTC()
+	for _, event := range events {
+		if packetType == PACKET_STATUS_SEND {
+			if event.Code != 0 || event.Sequence < ibcPacketTracker.Sequence {
+				continue
+			}
+
+			ibcPacketTracker.InFlightPackets[event.Sequence] = &InFlightPacket{
+				Sequence:   event.Sequence,
+				PacketType: PACKET_STATUS_RECV,
+				Timeout: Timeout{
+					Height:    event.TimeoutHeight,
+					Timestamp: event.TimeoutTimestamp,
+				},
+
+				Sent:          eventTime(event.BlockTime, now),
+				SentBlockTime: event.BlockTime,
+			}
+			ibcPacketTracker.setSucceedPacket(packetType, event)
+
+			msg := fmt.Sprintf("ibc packet succeed: %s", ibcPacketTracker.packetString(packetType, event.Sequence))
+			logger.Info(msg)
+
+			continue
+		}
+
+		inFlightPacket, ok := ibcPacketTracker.InFlightPackets[event.Sequence]
+		if !ok || !inFlightPacket.isWaitingFor(packetType) {
+			continue
+		}
+
+		if event.Code != 0 {
+			msg := fmt.Sprintf("ibc tx not successed: %s", ibcPacketTracker.packetString(packetType, event.Sequence))
+			logger.Debug(msg)
+
+			ibcPacketTracker.Relayers.failed(ibcPacketTracker.relayedOn(packetType), event)
+
+			// timeout_packet can be submitted again, the packet keeps waiting for it
+			if packetType == PACKET_STATUS_TIMEOUT {
+				continue
+			}
+
+			delete(ibcPacketTracker.InFlightPackets, event.Sequence)
+			missedPackets = append(missedPackets, *inFlightPacket)
+			ibcPacketTracker.FailedCnt++
+
+			continue
+		}
+
+		msg := fmt.Sprintf("ibc packet succeed: %s", ibcPacketTracker.packetString(packetType, event.Sequence))
+		logger.Info(msg)
+
+		ibcPacketTracker.setSucceedPacket(packetType, event)
+
+		switch packetType {
+		case PACKET_STATUS_RECV:
+			inFlightPacket.PacketType = PACKET_STATUS_ACK
+			inFlightPacket.Received = eventTime(event.BlockTime, now)
+			inFlightPacket.ReceivedBlockTime = event.BlockTime
+			inFlightPacket.RecvRelayer = event.Signer
+
+			recvLatency, measured := blockTimeLatency(inFlightPacket.SentBlockTime, event.BlockTime)
+			if measured {
+				ibcPacketTracker.Latencies.observe(LATENCY_SEND_TO_RECV, recvLatency)
+			}
+			ibcPacketTracker.Relayers.relayed(ibcPacketTracker.relayedOn(packetType), packetType, event, recvLatency, measured)
+
+			// relayed well, but the packet failed in the app of destination and would be refunded on source
+			if event.ErrorAck {
+				ibcPacketTracker.ErrorAckCnt++
+
+				msg := fmt.Sprintf("error acknowledgement(%s): %s", event.AckError, ibcPacketTracker.packetString(packetType, event.Sequence))
+				logger.Warn(msg)
+			}
+		case PACKET_STATUS_ACK:
+			delete(ibcPacketTracker.InFlightPackets, event.Sequence)
+
+			if len(ibcPacketTracker.RelayedPackets) >= MAX_RELAYED_PACKETS {
+				ibcPacketTracker.RelayedPackets = ibcPacketTracker.RelayedPackets[1:]
+			}
+			latency, latencyMeasured := blockTimeLatency(inFlightPacket.SentBlockTime, event.BlockTime)
+			recvLatency, _ := blockTimeLatency(inFlightPacket.SentBlockTime, inFlightPacket.ReceivedBlockTime)
+			ackLatency, ackMeasured := blockTimeLatency(inFlightPacket.ReceivedBlockTime, event.BlockTime)
+			relayedPacket := RelayedPacket{
+				Sequence:    event.Sequence,
+				Latency:     latency,
+				RecvLatency: recvLatency,
+				AckLatency:  ackLatency,
+
+				RecvRelayer: inFlightPacket.RecvRelayer,
+				AckRelayer:  event.Signer,
+			}
+			ibcPacketTracker.RelayedPackets = append(ibcPacketTracker.RelayedPackets, relayedPacket)
+
+			if ackMeasured {
+				ibcPacketTracker.Latencies.observe(LATENCY_RECV_TO_ACK, ackLatency)
+			}
+			if latencyMeasured {
+				ibcPacketTracker.Latencies.observe(LATENCY_SEND_TO_ACK, latency)
+			}
+			ibcPacketTracker.Relayers.relayed(ibcPacketTracker.relayedOn(packetType), packetType, event, ackLatency, ackMeasured)
+
+			ibcPacketTracker.Health = true
+			ibcPacketTracker.MissedCnt = 0
+			ibcPacketTracker.RelayedCnt++
+		case PACKET_STATUS_TIMEOUT:
+			delete(ibcPacketTracker.InFlightPackets, event.Sequence)
+
+			// timed out on source before found timed out on destination
+			if inFlightPacket.PacketType == PACKET_STATUS_RECV {
+				missedPackets = append(missedPackets, *inFlightPacket)
+			}
+			ibcPacketTracker.TimedOutCnt++
+			ibcPacketTracker.Relayers.relayed(ibcPacketTracker.relayedOn(packetType), packetType, event, 0, false)
+		}
+	}
+
+	if packetType == PACKET_STATUS_SEND {
+		for sequence := range ibcPacketTracker.InFlightPackets {
+			if sequence >= ibcPacketTracker.Sequence {
+				ibcPacketTracker.Sequence = sequence + 1
+			}
+		}
+
+		// drop the oldest packets, it's more likely to be missed than the newest
+		for len(ibcPacketTracker.InFlightPackets) > MAX_IN_FLIGHT_PACKETS {
+			inFlightPackets := ibcPacketTracker.GetInFlightPackets()
+			oldest := inFlightPackets[0]
+			delete(ibcPacketTracker.InFlightPackets, oldest.Sequence)
+			// timed out packets are already missed
+			if oldest.PacketType != PACKET_STATUS_TIMEOUT {
+				missedPackets = append(missedPackets, oldest)
+			}
+		}
+	}
+
+	return missedPackets
+}
+
+// the caller should hold the lock
+func (ibcPacketTracker *IBCPacketTracker) setSucceedPacket(packetType PacketTypes, event rpc.IBCPacketEvent) {
+	succeedPacket, ok := ibcPacketTracker.LatestSucceedPackets[packetType.String()]
+	if ok && succeedPacket.Sequence > event.Sequence {
+		return
 	}
 
-	ibcPacketTracker.transitStatus(timeoutHeight, timeoutTimestamp)
+	ibcPacketTracker.LatestSucceedPackets[packetType.String()] = SucceedPacket{
+		Hash:     event.Hash,
+		Sequence: event.Sequence,
+		Data:     event.Data,
+		AckError: event.AckError,
+	}
+}
+
+// timed out packets which are never received wait for timeout_packet on source, return them as missed
+func (ibcPacketTracker *IBCPacketTracker) timeouts(ctx context.Context) ([]InFlightPacket, error) {
+	ibcPacketTracker.mutex.RLock()
+	waitingRecv := make([]InFlightPacket, 0)
+	for _, inFlightPacket := range ibcPacketTracker.InFlightPackets {
+		if inFlightPacket.PacketType != PACKET_STATUS_RECV {
+			continue
+		}
+
+		waitingRecv = append(waitingRecv, *inFlightPacket)
+	}
+	ibcPacketTracker.mutex.RUnlock()
+
+	if len(waitingRecv) == 0 {
+		return nil, nil
+	}
+
+	// timeout timestamps are compared with the block time of destination, not the local clock
+	latestHeight, latestTime, err := ibcPacketTracker.Destination.rpc.GetLatestHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	ibcPacketTracker.lock()
+	defer ibcPacketTracker.mutex.Unlock()
+
+	var timeouts []InFlightPacket
+	for _, inFlightPacket := range waitingRecv {
+		if !ibcPacketTracker.isTimeout(&inFlightPacket, latestHeight, latestTime) {
+			continue
+		}
+
+		current, ok := ibcPacketTracker.InFlightPackets[inFlightPacket.Sequence]
+		if !ok || current.PacketType != PACKET_STATUS_RECV {
+			continue
+		}
+
+		msg := fmt.Sprintf("timeout ibc tx: %s", ibcPacketTracker.packetString(PACKET_STATUS_RECV, inFlightPacket.Sequence))
+		logger.Debug(msg)
+
+		current.PacketType = PACKET_STATUS_TIMEOUT
+		current.TimedOut = now.UTC()
+		timeouts = append(timeouts, inFlightPacket)
+	}
 
-	return false, nil
+	return timeouts, nil
 }
@@ -0,0 +1,400 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/retry"
+	"github.com/dlvlabs/ibcmon/client/rpc"
+
+	"github.com/cometbft/cometbft/p2p"
+	coreTypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpcTypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
+)
+
+func newTestTracker() *IBCPacketTracker {
+	return NewIBCPacketTracker(
+		1,
+		false,
+
+		nil, nil,
+		"src-1", "channel-0", "transfer",
+
+		nil, nil,
+		"dst-1", "channel-1", "transfer",
+	)
+}
+
+func sendEvent(sequence uint64) rpc.IBCPacketEvent {
+	return rpc.IBCPacketEvent{
+		PacketType: PACKET_STATUS_SEND.String(),
+		Sequence:   sequence,
+	}
+}
+
+func TestIBCPacketTrackerCopyOnWrite(t *testing.T) {
+	ibcPacketTracker := newTestTracker()
+
+	first := ibcPacketTracker.copy()
+	if ibcPacketTracker.copy() != first {
+		t.Fatal("unchanged tracker should share its copy")
+	}
+
+	ibcPacketTracker.lock()
+	ibcPacketTracker.applyEvents(PACKET_STATUS_SEND, []rpc.IBCPacketEvent{sendEvent(1)})
+	ibcPacketTracker.mutex.Unlock()
+
+	second := ibcPacketTracker.copy()
+	if second == first {
+		t.Fatal("changed tracker should be copied again")
+	}
+	if len(first.InFlightPackets) != 0 {
+		t.Fatalf("published copy was modified: %d in-flight packets", len(first.InFlightPackets))
+	}
+	if len(second.InFlightPackets) != 1 {
+		t.Fatalf("expected 1 in-flight packet, got %d", len(second.InFlightPackets))
+	}
+}
+
+// run with -race
+func TestIBCPacketTrackerConcurrentAccess(t *testing.T) {
+	ibcPacketTracker := newTestTracker()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for sequence := uint64(1); sequence <= 200; sequence++ {
+			ibcPacketTracker.lock()
+			ibcPacketTracker.applyEvents(PACKET_STATUS_SEND, []rpc.IBCPacketEvent{sendEvent(sequence)})
+			ibcPacketTracker.mutex.Unlock()
+
+			ibcPacketTracker.degrade(nil)
+		}
+	}()
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				copied := ibcPacketTracker.copy()
+				_ = copied.GetInFlightPackets()
+				_ = copied.GetRelayedPackets()
+
+				_ = ibcPacketTracker.state()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	copied := ibcPacketTracker.copy()
+	if len(copied.InFlightPackets) != 200 {
+		t.Fatalf("expected 200 in-flight packets, got %d", len(copied.InFlightPackets))
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	timeoutTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		timeout      Timeout
+		latestHeight clientTypes.Height
+		latestTime   time.Time
+
+		expected bool
+	}{
+		{
+			name:         "height not reached",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100)},
+			latestHeight: clientTypes.NewHeight(1, 99),
+			latestTime:   timeoutTime,
+			expected:     false,
+		},
+		{
+			name:         "height reached",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100)},
+			latestHeight: clientTypes.NewHeight(1, 100),
+			latestTime:   timeoutTime,
+			expected:     true,
+		},
+		{
+			name:         "revision bumped with lower height",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100)},
+			latestHeight: clientTypes.NewHeight(2, 5),
+			latestTime:   timeoutTime,
+			expected:     true,
+		},
+		{
+			name:         "higher height of older revision",
+			timeout:      Timeout{Height: clientTypes.NewHeight(2, 100)},
+			latestHeight: clientTypes.NewHeight(1, 1000),
+			latestTime:   timeoutTime,
+			expected:     false,
+		},
+		{
+			name:         "height 0 with timestamp not reached",
+			timeout:      Timeout{Timestamp: timeoutTime.UnixNano()},
+			latestHeight: clientTypes.NewHeight(1, 1000),
+			latestTime:   timeoutTime.Add(-time.Second),
+			expected:     false,
+		},
+		{
+			name:         "height 0 with timestamp reached",
+			timeout:      Timeout{Timestamp: timeoutTime.UnixNano()},
+			latestHeight: clientTypes.NewHeight(1, 1),
+			latestTime:   timeoutTime,
+			expected:     true,
+		},
+		{
+			name:         "timestamp 0 with height not reached",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100)},
+			latestHeight: clientTypes.NewHeight(1, 50),
+			latestTime:   timeoutTime.Add(time.Hour),
+			expected:     false,
+		},
+		{
+			name:         "timestamp 0 with height reached",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100)},
+			latestHeight: clientTypes.NewHeight(1, 101),
+			latestTime:   time.Unix(0, 1),
+			expected:     true,
+		},
+		{
+			name:         "both set, neither reached",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100), Timestamp: timeoutTime.UnixNano()},
+			latestHeight: clientTypes.NewHeight(1, 99),
+			latestTime:   timeoutTime.Add(-time.Second),
+			expected:     false,
+		},
+		{
+			name:         "both set, only height reached",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100), Timestamp: timeoutTime.UnixNano()},
+			latestHeight: clientTypes.NewHeight(1, 100),
+			latestTime:   timeoutTime.Add(-time.Second),
+			expected:     true,
+		},
+		{
+			name:         "both set, only timestamp reached",
+			timeout:      Timeout{Height: clientTypes.NewHeight(1, 100), Timestamp: timeoutTime.UnixNano()},
+			latestHeight: clientTypes.NewHeight(1, 99),
+			latestTime:   timeoutTime,
+			expected:     true,
+		},
+	}
+
+	ibcPacketTracker := newTestTracker()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inFlightPacket := &InFlightPacket{
+				Sequence:   1,
+				PacketType: PACKET_STATUS_RECV,
+				Timeout:    test.timeout,
+			}
+
+			result := ibcPacketTracker.isTimeout(inFlightPacket, test.latestHeight, test.latestTime)
+			if result != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, result)
+			}
+		})
+	}
+}
+
+// rpc server returning the status of destination
+func newStatusServer(t *testing.T, chainId string, height int64, blockTime time.Time) *rpc.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request rpcTypes.RPCRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		status := &coreTypes.ResultStatus{
+			NodeInfo: p2p.DefaultNodeInfo{Network: chainId},
+			SyncInfo: coreTypes.SyncInfo{
+				LatestBlockHeight: height,
+				LatestBlockTime:   blockTime,
+			},
+		}
+		err = json.NewEncoder(w).Encode(rpcTypes.NewRPCSuccessResponse(request.ID, status))
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := rpc.New([]string{server.URL}, retry.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestTimeouts(t *testing.T) {
+	blockTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		packetType PacketTypes
+		timeout    Timeout
+
+		timedOut bool
+	}{
+		{
+			name:       "revision bumped",
+			packetType: PACKET_STATUS_RECV,
+			timeout:    Timeout{Height: clientTypes.NewHeight(1, 100)},
+			timedOut:   true,
+		},
+		{
+			name:       "height of next revision",
+			packetType: PACKET_STATUS_RECV,
+			timeout:    Timeout{Height: clientTypes.NewHeight(3, 10)},
+			timedOut:   false,
+		},
+		{
+			name:       "height 0 with timestamp",
+			packetType: PACKET_STATUS_RECV,
+			timeout:    Timeout{Timestamp: blockTime.UnixNano()},
+			timedOut:   true,
+		},
+		{
+			name:       "timestamp 0 with height",
+			packetType: PACKET_STATUS_RECV,
+			timeout:    Timeout{Height: clientTypes.NewHeight(2, 51)},
+			timedOut:   false,
+		},
+		{
+			name:       "both set",
+			packetType: PACKET_STATUS_RECV,
+			timeout:    Timeout{Height: clientTypes.NewHeight(2, 100), Timestamp: blockTime.UnixNano()},
+			timedOut:   true,
+		},
+		{
+			name:       "received packet",
+			packetType: PACKET_STATUS_ACK,
+			timeout:    Timeout{Timestamp: blockTime.UnixNano()},
+			timedOut:   false,
+		},
+	}
+
+	ibcPacketTracker := newTestTracker()
+	// destination is at height 50 of revision 2
+	ibcPacketTracker.Destination.rpc = newStatusServer(t, "dst-2", 50, blockTime)
+
+	for i, test := range tests {
+		sequence := uint64(i + 1)
+		ibcPacketTracker.InFlightPackets[sequence] = &InFlightPacket{
+			Sequence:   sequence,
+			PacketType: test.packetType,
+			Timeout:    test.timeout,
+		}
+	}
+
+	timeouts, err := ibcPacketTracker.timeouts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timedOut := make(map[uint64]bool)
+	for _, inFlightPacket := range timeouts {
+		timedOut[inFlightPacket.Sequence] = true
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sequence := uint64(i + 1)
+			if timedOut[sequence] != test.timedOut {
+				t.Fatalf("expected timed out %t, got %t", test.timedOut, timedOut[sequence])
+			}
+
+			inFlightPacket := ibcPacketTracker.InFlightPackets[sequence]
+			if test.timedOut && (inFlightPacket.PacketType != PACKET_STATUS_TIMEOUT || inFlightPacket.TimedOut.IsZero()) {
+				t.Fatalf("timed out packet should wait for timeout_packet, got %s", inFlightPacket.PacketType)
+			}
+			if !test.timedOut && inFlightPacket.PacketType != test.packetType {
+				t.Fatalf("expected %s, got %s", test.packetType, inFlightPacket.PacketType)
+			}
+		})
+	}
+}
+
+func TestIsWaitingFor(t *testing.T) {
+	tests := []struct {
+		name string
+
+		waiting    PacketTypes
+		packetType PacketTypes
+
+		expected bool
+	}{
+		{name: "recv", waiting: PACKET_STATUS_RECV, packetType: PACKET_STATUS_RECV, expected: true},
+		{name: "ack before recv", waiting: PACKET_STATUS_RECV, packetType: PACKET_STATUS_ACK, expected: false},
+		{name: "timeout before found timed out", waiting: PACKET_STATUS_RECV, packetType: PACKET_STATUS_TIMEOUT, expected: true},
+		{name: "timeout", waiting: PACKET_STATUS_TIMEOUT, packetType: PACKET_STATUS_TIMEOUT, expected: true},
+		{name: "timeout after recv", waiting: PACKET_STATUS_ACK, packetType: PACKET_STATUS_TIMEOUT, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inFlightPacket := &InFlightPacket{Sequence: 1, PacketType: test.waiting}
+			result := inFlightPacket.isWaitingFor(test.packetType)
+			if result != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestUnconfirmedTimeouts(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+
+		packetType PacketTypes
+		timedOut   time.Time
+
+		unconfirmed bool
+	}{
+		{name: "timed out long ago", packetType: PACKET_STATUS_TIMEOUT, timedOut: now.Add(-1 * time.Hour), unconfirmed: true},
+		{name: "timed out recently", packetType: PACKET_STATUS_TIMEOUT, timedOut: now.Add(-1 * time.Minute), unconfirmed: false},
+		{name: "waiting for recv", packetType: PACKET_STATUS_RECV, unconfirmed: false},
+		{name: "waiting for ack", packetType: PACKET_STATUS_ACK, unconfirmed: false},
+	}
+
+	ibcPacketTracker := newTestTracker()
+	for i, test := range tests {
+		sequence := uint64(i + 1)
+		ibcPacketTracker.InFlightPackets[sequence] = &InFlightPacket{
+			Sequence:   sequence,
+			PacketType: test.packetType,
+			TimedOut:   test.timedOut,
+		}
+	}
+
+	unconfirmed := make(map[uint64]bool)
+	for _, sequence := range ibcPacketTracker.unconfirmedTimeouts(10 * time.Minute) {
+		unconfirmed[sequence] = true
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sequence := uint64(i + 1)
+			if unconfirmed[sequence] != test.unconfirmed {
+				t.Fatalf("expected unconfirmed %t, got %t", test.unconfirmed, unconfirmed[sequence])
+			}
+		})
+	}
+}
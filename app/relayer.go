@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/rpc"
+)
+
+// max number of relayers kept per channel, the one relayed least recently is dropped
+const MAX_RELAYERS = 100
+
+type (
+	// chainId/address => RelayerStat
+	RelayerStats map[string]RelayerStat
+	// txs signed by a relayer for the packets of a channel since tracking started
+	RelayerStat struct {
+		ChainId string
+		Address string
+		// memo of the latest tx
+		Memo string
+
+		// numbers of packets relayed with successful recv_packet, acknowledge_packet and timeout_packet
+		RecvCnt    uint64
+		AckCnt     uint64
+		TimeoutCnt uint64
+		// number of failed txs
+		FailedCnt uint64
+
+		// sum and number of latencies from send_packet to recv_packet and from recv_packet to acknowledge_packet,
+		// only the packets with known block times are counted
+		LatencySum time.Duration
+		LatencyCnt uint64
+		// block time of the latest tx, zero until a tx with known block time
+		LastRelayed time.Time
+	}
+)
+
+func relayerKey(chainId, address string) string {
+	return fmt.Sprintf("%s/%s", chainId, address)
+}
+
+// record the packet relayed by the signer of the event, the latency is added only if it's measured from block times,
+// the caller should hold the lock of the tracker
+func (relayers RelayerStats) relayed(chainId string, packetType PacketTypes, event rpc.IBCPacketEvent, latency time.Duration, measured bool) {
+	relayers.update(chainId, event, func(relayer *RelayerStat) {
+		switch packetType {
+		case PACKET_STATUS_RECV:
+			relayer.RecvCnt++
+		case PACKET_STATUS_ACK:
+			relayer.AckCnt++
+		case PACKET_STATUS_TIMEOUT:
+			relayer.TimeoutCnt++
+		}
+		if measured && packetType != PACKET_STATUS_TIMEOUT {
+			relayer.LatencySum += max(latency, 0)
+			relayer.LatencyCnt++
+		}
+		if !event.BlockTime.IsZero() {
+			relayer.LastRelayed = event.BlockTime
+		}
+	})
+}
+
+// record the failed tx of the signer of the event, the caller should hold the lock of the tracker
+func (relayers RelayerStats) failed(chainId string, event rpc.IBCPacketEvent) {
+	relayers.update(chainId, event, func(relayer *RelayerStat) {
+		relayer.FailedCnt++
+	})
+}
+
+func (relayers RelayerStats) update(chainId string, event rpc.IBCPacketEvent, update func(relayer *RelayerStat)) {
+	// the signer is unknown if the tx doesn't have the events
+	if event.Signer == "" {
+		return
+	}
+
+	key := relayerKey(chainId, event.Signer)
+	relayer, ok := relayers[key]
+	if !ok {
+		relayers.evict()
+		relayer = RelayerStat{
+			ChainId: chainId,
+			Address: event.Signer,
+		}
+	}
+
+	if event.Memo != "" {
+		relayer.Memo = event.Memo
+	}
+	update(&relayer)
+
+	relayers[key] = relayer
+}
+
+// drop the relayer relayed least recently if it's full
+func (relayers RelayerStats) evict() {
+	if len(relayers) < MAX_RELAYERS {
+		return
+	}
+
+	oldest := ""
+	for key, relayer := range relayers {
+		if oldest == "" || relayer.LastRelayed.Before(relayers[oldest].LastRelayed) {
+			oldest = key
+		}
+	}
+	delete(relayers, oldest)
+}
+
+func (relayers RelayerStats) copy() RelayerStats {
+	return maps.Clone(relayers)
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/rpc"
+)
+
+func TestRelayed(t *testing.T) {
+	blockTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		packetType PacketTypes
+		event      rpc.IBCPacketEvent
+		latency    time.Duration
+		measured   bool
+
+		expected RelayerStat
+	}{
+		{
+			name:       "recv with latency",
+			packetType: PACKET_STATUS_RECV,
+			event:      rpc.IBCPacketEvent{Signer: "cosmos1relayer", Memo: "hermes 1.13.1", BlockTime: blockTime},
+			latency:    10 * time.Second,
+			measured:   true,
+			expected: RelayerStat{
+				ChainId: "dst-1", Address: "cosmos1relayer", Memo: "hermes 1.13.1",
+				RecvCnt: 2, LatencySum: 15 * time.Second, LatencyCnt: 2, LastRelayed: blockTime,
+			},
+		},
+		{
+			name:       "ack without block times",
+			packetType: PACKET_STATUS_ACK,
+			event:      rpc.IBCPacketEvent{Signer: "cosmos1relayer"},
+			latency:    10 * time.Second,
+			measured:   false,
+			expected: RelayerStat{
+				ChainId: "dst-1", Address: "cosmos1relayer", Memo: "rly v2",
+				RecvCnt: 1, AckCnt: 1, LatencySum: 5 * time.Second, LatencyCnt: 1, LastRelayed: blockTime.Add(-1 * time.Minute),
+			},
+		},
+		{
+			name:       "timeout doesn't have latency",
+			packetType: PACKET_STATUS_TIMEOUT,
+			event:      rpc.IBCPacketEvent{Signer: "cosmos1relayer", BlockTime: blockTime},
+			latency:    10 * time.Second,
+			measured:   true,
+			expected: RelayerStat{
+				ChainId: "dst-1", Address: "cosmos1relayer", Memo: "rly v2",
+				RecvCnt: 1, TimeoutCnt: 1, LatencySum: 5 * time.Second, LatencyCnt: 1, LastRelayed: blockTime,
+			},
+		},
+		{
+			name:       "negative latency is counted as zero",
+			packetType: PACKET_STATUS_RECV,
+			event:      rpc.IBCPacketEvent{Signer: "cosmos1relayer", BlockTime: blockTime},
+			latency:    -1 * time.Second,
+			measured:   true,
+			expected: RelayerStat{
+				ChainId: "dst-1", Address: "cosmos1relayer", Memo: "rly v2",
+				RecvCnt: 2, LatencySum: 5 * time.Second, LatencyCnt: 2, LastRelayed: blockTime,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			relayers := RelayerStats{
+				relayerKey("dst-1", "cosmos1relayer"): {
+					ChainId: "dst-1", Address: "cosmos1relayer", Memo: "rly v2",
+					RecvCnt: 1, LatencySum: 5 * time.Second, LatencyCnt: 1, LastRelayed: blockTime.Add(-1 * time.Minute),
+				},
+			}
+
+			relayers.relayed("dst-1", test.packetType, test.event, test.latency, test.measured)
+
+			result := relayers[relayerKey("dst-1", "cosmos1relayer")]
+			if result != test.expected {
+				t.Fatalf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestRelayerUpdate(t *testing.T) {
+	tests := []struct {
+		name string
+
+		event rpc.IBCPacketEvent
+
+		expected RelayerStats
+	}{
+		{
+			name: "new relayer",
+			event: rpc.IBCPacketEvent{
+				Signer: "cosmos1relayer",
+				Memo:   "hermes 1.13.1",
+			},
+			expected: RelayerStats{
+				relayerKey("src-1", "cosmos1relayer"): {ChainId: "src-1", Address: "cosmos1relayer", Memo: "hermes 1.13.1", FailedCnt: 1},
+			},
+		},
+		{
+			name:     "unknown signer",
+			event:    rpc.IBCPacketEvent{},
+			expected: RelayerStats{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			relayers := make(RelayerStats)
+			relayers.failed("src-1", test.event)
+
+			if len(relayers) != len(test.expected) {
+				t.Fatalf("expected %d relayers, got %+v", len(test.expected), relayers)
+			}
+			for key, expected := range test.expected {
+				if relayers[key] != expected {
+					t.Fatalf("expected %+v, got %+v", expected, relayers[key])
+				}
+			}
+		})
+	}
+}
+
+func TestEvict(t *testing.T) {
+	blockTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// relayer-i relayed at i minutes after the block time, except the oldest one
+	newRelayers := func(n int, oldest int) RelayerStats {
+		relayers := make(RelayerStats)
+		for i := range n {
+			address := fmt.Sprintf("relayer-%d", i)
+			lastRelayed := blockTime.Add(time.Duration(i) * time.Minute)
+			if i == oldest {
+				lastRelayed = blockTime.Add(-1 * time.Hour)
+			}
+			relayers[relayerKey("src-1", address)] = RelayerStat{ChainId: "src-1", Address: address, LastRelayed: lastRelayed}
+		}
+		return relayers
+	}
+
+	tests := []struct {
+		name string
+
+		relayers RelayerStats
+
+		expectedLen int
+		evicted     string
+	}{
+		{
+			name:        "not full",
+			relayers:    newRelayers(MAX_RELAYERS-1, 10),
+			expectedLen: MAX_RELAYERS - 1,
+		},
+		{
+			name:        "full",
+			relayers:    newRelayers(MAX_RELAYERS, 10),
+			expectedLen: MAX_RELAYERS - 1,
+			evicted:     relayerKey("src-1", "relayer-10"),
+		},
+		{
+			name:        "relayer never relayed with block time is the oldest",
+			relayers:    newRelayers(MAX_RELAYERS, 10),
+			expectedLen: MAX_RELAYERS - 1,
+			evicted:     relayerKey("src-1", "relayer-20"),
+		},
+	}
+	// relayer-20 doesn't have the block time of its tx
+	tests[2].relayers[relayerKey("src-1", "relayer-20")] = RelayerStat{ChainId: "src-1", Address: "relayer-20"}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.relayers.evict()
+
+			if len(test.relayers) != test.expectedLen {
+				t.Fatalf("expected %d relayers, got %d", test.expectedLen, len(test.relayers))
+			}
+			if _, ok := test.relayers[test.evicted]; test.evicted != "" && ok {
+				t.Fatalf("expected %s to be evicted", test.evicted)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dlvlabs/ibcmon/client/grpc"
+	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/pkg/errors"
+)
+
+// strategies to discover the sequence which tracking starts from
+const (
+	SEQUENCE_SOURCE_NEXT_SEQUENCE_SEND = "next_sequence_send"
+	// the highest packet commitment, for chains not support `NextSequenceSend` query
+	SEQUENCE_SOURCE_PACKET_COMMITMENT = "packet_commitment"
+	// the latest send_packet event, for chains without pending packets
+	SEQUENCE_SOURCE_TX_SEARCH = "tx_search"
+	// no packet is found, the channel never sent a packet
+	SEQUENCE_SOURCE_INITIAL = "initial"
+)
+
+// set the sequence which the tracker starts from, with the first available strategy
+func (ibcPacketTracker *IBCPacketTracker) discoverSequence(ctx context.Context) error {
+	nextSequence, err := ibcPacketTracker.Source.getNextSequenceSend(ctx)
+	if err == nil {
+		ibcPacketTracker.setSequence(nextSequence, SEQUENCE_SOURCE_NEXT_SEQUENCE_SEND)
+		return nil
+	}
+	if !errors.Is(errors.Cause(err), grpc.UNIMPLMENTED) {
+		return err
+	}
+
+	msg := fmt.Sprintf("not support `NextSequenceSend` query, fall back to other strategies: %s", ibcPacketTracker.String())
+	logger.Info(msg)
+
+	commitments, err := ibcPacketTracker.Source.getPacketCommitments(ctx)
+	if err != nil {
+		return err
+	}
+	if len(commitments) > 0 {
+		ibcPacketTracker.setSequence(slices.Max(commitments)+1, SEQUENCE_SOURCE_PACKET_COMMITMENT)
+		return nil
+	}
+
+	sequence, found, err := ibcPacketTracker.Source.rpc.GetLatestIBCPacketSequence(ctx, ibcPacketTracker, PACKET_STATUS_SEND.String())
+	if err != nil {
+		return err
+	}
+	if found {
+		ibcPacketTracker.setSequence(sequence+1, SEQUENCE_SOURCE_TX_SEARCH)
+		return nil
+	}
+
+	// sequence of ibc packet starts from 1
+	ibcPacketTracker.setSequence(1, SEQUENCE_SOURCE_INITIAL)
+	return nil
+}
+
+func (ibcPacketTracker *IBCPacketTracker) setSequence(sequence uint64, sequenceSource string) {
+	ibcPacketTracker.lock()
+	defer ibcPacketTracker.mutex.Unlock()
+
+	ibcPacketTracker.Sequence = sequence
+	ibcPacketTracker.SequenceSource = sequenceSource
+}
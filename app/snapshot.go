@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+)
+
+// interval of publishing a snapshot for the changes of trackers
+const SNAPSHOT_INTERVAL = 1 * time.Second
+
+// Snapshot is an immutable copy of Store.
+// Writers publish a new snapshot on every change, so readers like the server don't need any lock.
+type Snapshot struct {
+	// increased on every publish
+	Version uint64
+	Updated time.Time
+
+	BaseChainIds []string
+	IBCInfo      IBCInfo
+	Changelog    []TopologyChange
+}
+
+// Snapshot returns the latest published snapshot, it must not be modified
+func (store *Store) Snapshot() *Snapshot {
+	snapshot := store.snapshot.Load()
+	if snapshot == nil {
+		return &Snapshot{IBCInfo: make(IBCInfo)}
+	}
+	return snapshot
+}
+
+// base chain which the path between the chains is discovered from
+func (snapshot *Snapshot) BaseChainId(chainId, counterpartyChainId string) string {
+	if slices.Contains(snapshot.BaseChainIds, chainId) {
+		return chainId
+	}
+	return counterpartyChainId
+}
+
+// publish a snapshot of app.Store, app.storeMutex should be held
+func (app *App) publish() {
+	var version uint64 = 1
+	if prev := app.Store.snapshot.Load(); prev != nil {
+		version = prev.Version + 1
+	}
+
+	app.Store.snapshot.Store(&Snapshot{
+		Version: version,
+		Updated: app.Store.Updated,
+
+		BaseChainIds: slices.Clone(app.Store.BaseChainIds),
+		IBCInfo:      app.Store.IBCInfo.copy(),
+		Changelog:    slices.Clone(app.Store.Changelog),
+	})
+}
+
+// trackers are updated with their own locks, so their changes are published in batch
+func (app *App) runSnapshotPublisher(ctx context.Context) {
+	ticker := time.NewTicker(SNAPSHOT_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !app.trackersChanged.Swap(false) {
+				continue
+			}
+
+			app.storeMutex.Lock()
+			app.publish()
+			app.storeMutex.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// shallow copy of app.Store.IBCInfo for the jobs,
+// Clients are replaced instead of modified, so they can be iterated without lock
+func (app *App) ibcInfo() IBCInfo {
+	app.storeMutex.Lock()
+	defer app.storeMutex.Unlock()
+
+	ibcInfo := make(IBCInfo, len(app.Store.IBCInfo))
+	for chainId, clients := range app.Store.IBCInfo {
+		ibcInfo[chainId] = clients
+	}
+	return ibcInfo
+}
+
+func (ibcInfo IBCInfo) copy() IBCInfo {
+	copied := make(IBCInfo, len(ibcInfo))
+	for chainId, clients := range ibcInfo {
+		copied[chainId] = make(Clients, len(clients))
+		for clientId, client := range clients {
+			copied[chainId][clientId] = client.copy()
+		}
+	}
+	return copied
+}
+
+// Degraded and Unrelayed are replaced instead of modified, so they are shared
+func (client *Client) copy() *Client {
+	copied := *client
+
+	copied.Connections = make(Connections, len(client.Connections))
+	for connectionId, channels := range client.Connections {
+		copied.Connections[connectionId] = make(Channels, len(channels))
+		for channelId, channel := range channels {
+			copied.Connections[connectionId][channelId] = channel.copy()
+		}
+	}
+	copied.ConnectionStates = maps.Clone(client.ConnectionStates)
+	if client.V2 != nil {
+		copied.V2 = client.V2.copy()
+	}
+
+	return &copied
+}
+
+func (channel *Channel) copy() *Channel {
+	copied := *channel
+	if channel.Counterparty != nil {
+		counterparty := *channel.Counterparty
+		copied.Counterparty = &counterparty
+	}
+	if channel.IBCPacketTracker != nil {
+		copied.IBCPacketTracker = channel.IBCPacketTracker.copy()
+	}
+	return &copied
+}
+
+// copy exported fields of the tracker,
+// the copy is made only if the tracker changed after the last copy, otherwise it's shared between snapshots
+func (ibcPacketTracker *IBCPacketTracker) copy() *IBCPacketTracker {
+	ibcPacketTracker.mutex.Lock()
+	defer ibcPacketTracker.mutex.Unlock()
+
+	if ibcPacketTracker.published != nil {
+		return ibcPacketTracker.published
+	}
+
+	inFlightPackets := make(InFlightPackets, len(ibcPacketTracker.InFlightPackets))
+	for sequence, inFlightPacket := range ibcPacketTracker.InFlightPackets {
+		copied := *inFlightPacket
+		inFlightPackets[sequence] = &copied
+	}
+
+	ibcPacketTracker.published = &IBCPacketTracker{
+		Updated: ibcPacketTracker.Updated,
+
+		Health: ibcPacketTracker.Health,
+
+		Sequence:        ibcPacketTracker.Sequence,
+		SequenceSource:  ibcPacketTracker.SequenceSource,
+		InFlightPackets: inFlightPackets,
+		RelayedPackets:  ibcPacketTracker.GetRelayedPackets(),
+
+		LatestSucceedPackets: ibcPacketTracker.GetLatestSucceedPackets(),
+		MissedCnt:            ibcPacketTracker.MissedCnt,
+
+		RelayedCnt:  ibcPacketTracker.RelayedCnt,
+		TimedOutCnt: ibcPacketTracker.TimedOutCnt,
+		FailedCnt:   ibcPacketTracker.FailedCnt,
+		ErrorAckCnt: ibcPacketTracker.ErrorAckCnt,
+		Latencies:   ibcPacketTracker.Latencies.copy(),
+		Relayers:    ibcPacketTracker.Relayers.copy(),
+
+		Degraded: ibcPacketTracker.Degraded,
+
+		Source:      ibcPacketTracker.Source,
+		Destination: ibcPacketTracker.Destination,
+	}
+
+	return ibcPacketTracker.published
+}
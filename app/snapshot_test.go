@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/rpc"
+)
+
+func newTestApp() *App {
+	return &App{
+		rpcs:  make(RPCs),
+		grpcs: make(GRPCs),
+
+		Store: Store{
+			BaseChainIds: []string{"src-1"},
+			IBCInfo:      make(IBCInfo),
+		},
+
+		trackers:    make(map[string]*runningTracker),
+		subscribers: make(packetSubscribers),
+	}
+}
+
+func newTestClients(ibcPacketTracker *IBCPacketTracker) Clients {
+	return Clients{
+		"07-tendermint-0": &Client{
+			Health:     true,
+			Status:     "Active",
+			ClientType: "07-tendermint",
+			ChainId:    "dst-1",
+
+			Connections: Connections{
+				"connection-0": Channels{
+					"channel-0": &Channel{
+						PortId: "transfer",
+						Counterparty: &Counterparty{
+							ClientId:     "07-tendermint-1",
+							ConnectionId: "connection-1",
+							ChannelId:    "channel-1",
+							PortId:       "transfer",
+						},
+						State: STATE_OPEN,
+
+						IBCPacketTracker: ibcPacketTracker,
+					},
+				},
+			},
+		},
+	}
+}
+
+func snapshotTracker(snapshot *Snapshot) *IBCPacketTracker {
+	return snapshot.IBCInfo["src-1"]["07-tendermint-0"].Connections["connection-0"]["channel-0"].IBCPacketTracker
+}
+
+// run with -race
+func TestSnapshotPublisher(t *testing.T) {
+	app := newTestApp()
+	ibcPacketTracker := newTestTracker()
+	app.replaceClients("src-1", newTestClients(ibcPacketTracker))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.runSnapshotPublisher(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for sequence := uint64(1); sequence <= 50; sequence++ {
+			ibcPacketTracker.lock()
+			ibcPacketTracker.applyEvents(PACKET_STATUS_SEND, []rpc.IBCPacketEvent{sendEvent(sequence)})
+			ibcPacketTracker.mutex.Unlock()
+
+			app.trackersChanged.Store(true)
+		}
+	}()
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				snapshot := app.Store.Snapshot()
+				for _, clients := range snapshot.IBCInfo {
+					for clientId, client := range clients {
+						for _, path := range client.Paths(clientId) {
+							if path.Channel.IBCPacketTracker != nil {
+								_ = path.Channel.IBCPacketTracker.GetInFlightPackets()
+							}
+						}
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// the last change is published within SNAPSHOT_INTERVAL
+	deadline := time.Now().Add(5 * SNAPSHOT_INTERVAL)
+	for {
+		inFlightPackets := len(snapshotTracker(app.Store.Snapshot()).InFlightPackets)
+		if inFlightPackets == 50 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 50 in-flight packets in snapshot, got %d", inFlightPackets)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestSnapshotIsImmutable(t *testing.T) {
+	app := newTestApp()
+	ibcPacketTracker := newTestTracker()
+	app.replaceClients("src-1", newTestClients(ibcPacketTracker))
+
+	snapshot := app.Store.Snapshot()
+
+	ibcPacketTracker.lock()
+	ibcPacketTracker.applyEvents(PACKET_STATUS_SEND, []rpc.IBCPacketEvent{sendEvent(1)})
+	ibcPacketTracker.mutex.Unlock()
+	app.updateStore(func() { app.Store.IBCInfo["src-1"]["07-tendermint-0"].Health = false })
+
+	if len(snapshotTracker(snapshot).InFlightPackets) != 0 {
+		t.Fatal("tracker in the published snapshot was modified")
+	}
+	if !snapshot.IBCInfo["src-1"]["07-tendermint-0"].Health {
+		t.Fatal("client in the published snapshot was modified")
+	}
+
+	latest := app.Store.Snapshot()
+	if latest.Version <= snapshot.Version {
+		t.Fatalf("expected a newer snapshot, got version %d after %d", latest.Version, snapshot.Version)
+	}
+	if len(snapshotTracker(latest).InFlightPackets) != 1 {
+		t.Fatal("tracker change is not published")
+	}
+}
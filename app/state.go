@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/logger"
+)
+
+const STATE_KEY = "app"
+
+type (
+	// persisted snapshot of app.Store, restored on startup
+	State struct {
+		Saved time.Time
+
+		IBCInfo IBCInfo
+
+		// trackerKey => TrackerState
+		Trackers map[string]TrackerState
+	}
+	TrackerState struct {
+		Health bool
+
+		Sequence        uint64
+		SequenceSource  string
+		InFlightPackets []InFlightPacket
+		RelayedPackets  []RelayedPacket
+
+		LatestSucceedPackets SucceedPackets
+		MissedCnt            uint64
+
+		RelayedCnt  uint64
+		TimedOutCnt uint64
+		FailedCnt   uint64
+		ErrorAckCnt uint64
+		Latencies   LatencyHistograms
+		Relayers    RelayerStats
+	}
+)
+
+func trackerKey(chainId, channelId, portId string) string {
+	return fmt.Sprintf("%s/%s/%s", chainId, channelId, portId)
+}
+
+// the latest changes are published under the lock, and the immutable snapshot is encoded and written outside it
+func (app *App) saveState() error {
+	app.storeMutex.Lock()
+	app.publish()
+	snapshot := app.Store.Snapshot()
+	app.storeMutex.Unlock()
+
+	state := State{
+		Saved: time.Now().UTC(),
+
+		IBCInfo:  snapshot.IBCInfo,
+		Trackers: make(map[string]TrackerState),
+	}
+
+	for chainId, clients := range snapshot.IBCInfo {
+		for clientId, client := range clients {
+			for _, path := range client.Paths(clientId) {
+				if path.Channel.IBCPacketTracker == nil {
+					continue
+				}
+
+				key := trackerKey(chainId, path.ChannelId, path.Channel.PortId)
+				state.Trackers[key] = path.Channel.IBCPacketTracker.state()
+			}
+		}
+	}
+
+	return app.state.Put(STATE_KEY, state)
+}
+
+func (app *App) loadState() (*State, error) {
+	var state State
+	found, err := app.state.Get(STATE_KEY, &state)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (app *App) runStateSaver(ctx context.Context) {
+	ticker := time.NewTicker(app.cfg.State.SaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := app.saveState()
+			if err != nil {
+				logger.Error(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
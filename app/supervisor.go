@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/alert"
+	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the first delay before a failed job is run again, doubled on each consecutive failure
+	SUPERVISOR_MIN_BACKOFF = 5 * time.Second
+	SUPERVISOR_MAX_BACKOFF = 10 * time.Minute
+)
+
+// Degradation is the error of a failing job, kept until the job succeeds again
+type Degradation struct {
+	Error string
+	Since time.Time
+	// number of consecutive failures
+	Failures uint64
+}
+
+// supervise runs step every interval until ctx is done,
+// a failed step is retried with exponential backoff instead of stopping the others.
+// report is called after every step with its error, nil on success.
+// the failure is alerted once per job until it succeeds again.
+func supervise(ctx context.Context, name string, interval time.Duration, step func(context.Context) error, report func(error)) {
+	alertKey := alert.Key{
+		Job:       name,
+		Condition: ALERT_JOB_FAILURE,
+	}
+
+	var backoff time.Duration
+	for {
+		err := step(ctx)
+		if ctx.Err() != nil {
+			msg := fmt.Sprintf("%s: %s", name, context.Canceled.Error())
+			logger.Info(msg)
+			return
+		}
+
+		delay := interval
+		if err != nil {
+			backoff = nextBackoff(backoff)
+			delay = backoff
+
+			logger.LogError(errors.Wrapf(err, "%s failed, retry in %s", name, delay))
+
+			msg := fmt.Sprintf("%s failed: %s", name, err.Error())
+			alert.Fire(alertKey, alert.ERROR, msg)
+		} else {
+			backoff = 0
+
+			msg := fmt.Sprintf("%s succeeded", name)
+			alert.Resolve(alertKey, msg)
+		}
+
+		if report != nil {
+			report(err)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			msg := fmt.Sprintf("%s: %s", name, context.Canceled.Error())
+			logger.Info(msg)
+			return
+		}
+	}
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff == 0 {
+		return SUPERVISOR_MIN_BACKOFF
+	}
+	return min(backoff*2, SUPERVISOR_MAX_BACKOFF)
+}
+
+// update the degradation with the result of a job, nil if the job succeeded
+func (degradation *Degradation) update(err error) *Degradation {
+	if err == nil {
+		return nil
+	}
+
+	if degradation == nil {
+		return &Degradation{
+			Error:    err.Error(),
+			Since:    time.Now().UTC(),
+			Failures: 1,
+		}
+	}
+
+	return &Degradation{
+		Error:    err.Error(),
+		Since:    degradation.Since,
+		Failures: degradation.Failures + 1,
+	}
+}
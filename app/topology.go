@@ -0,0 +1,340 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/alert"
+	"github.com/dlvlabs/ibcmon/logger"
+
+	"github.com/cosmos/ibc-go/v10/modules/core/exported"
+)
+
+const (
+	TOPOLOGY_CLIENT_ADDED      = "client_added"
+	TOPOLOGY_CLIENT_REMOVED    = "client_removed"
+	TOPOLOGY_CONNECTION_OPENED = "connection_opened"
+	TOPOLOGY_CONNECTION_CLOSED = "connection_closed"
+	TOPOLOGY_CHANNEL_OPENED    = "channel_opened"
+	TOPOLOGY_CHANNEL_CLOSED    = "channel_closed"
+	// e.g. OPEN => FLUSHING while upgrading
+	TOPOLOGY_CHANNEL_STATE_CHANGED = "channel_state_changed"
+
+	// number of latest topology changes kept in Store.Changelog
+	MAX_TOPOLOGY_CHANGES = 100
+)
+
+// TopologyChange is a client, connection or channel found or lost by refreshing ibc info or checking channel states,
+// "closed" means it's closed or not found among the open ones anymore
+type TopologyChange struct {
+	Time time.Time
+	// one of TOPOLOGY_*
+	Type string
+
+	ChainId             string
+	CounterpartyChainId string
+	ClientId            string
+	// empty for the changes of clients and IBC v2 paths
+	ConnectionId string
+	// empty for the changes of clients and connections
+	ChannelId string
+	PortId    string
+
+	// states of the channel before and after the change, empty for clients and connections
+	PrevState string
+	State     string
+}
+
+func (change TopologyChange) String() string {
+	switch change.Type {
+	case TOPOLOGY_CLIENT_ADDED, TOPOLOGY_CLIENT_REMOVED:
+		return fmt.Sprintf("%s: %s(%s) => %s", change.Type, change.ChainId, change.ClientId, change.CounterpartyChainId)
+	case TOPOLOGY_CONNECTION_OPENED, TOPOLOGY_CONNECTION_CLOSED:
+		return fmt.Sprintf("%s: %s(%s/%s) => %s", change.Type, change.ChainId, change.ClientId, change.ConnectionId, change.CounterpartyChainId)
+	default:
+		return fmt.Sprintf(
+			"%s(%s => %s): %s(%s/%s/%s/%s) => %s",
+			change.Type, change.PrevState, change.State,
+			change.ChainId, change.ClientId, change.ConnectionId, change.ChannelId, change.PortId, change.CounterpartyChainId,
+		)
+	}
+}
+
+func (change TopologyChange) severity() alert.Severity {
+	switch change.Type {
+	case TOPOLOGY_CLIENT_REMOVED, TOPOLOGY_CONNECTION_CLOSED, TOPOLOGY_CHANNEL_CLOSED:
+		return alert.WARNING
+	default:
+		return alert.INFO
+	}
+}
+
+func (change TopologyChange) isChannel() bool {
+	return change.Type == TOPOLOGY_CHANNEL_OPENED || change.Type == TOPOLOGY_CHANNEL_CLOSED || change.Type == TOPOLOGY_CHANNEL_STATE_CHANGED
+}
+
+// app.storeMutex should be held
+func (store *Store) record(changes []TopologyChange) {
+	store.Changelog = append(store.Changelog, changes...)
+	if len(store.Changelog) > MAX_TOPOLOGY_CHANGES {
+		store.Changelog = store.Changelog[len(store.Changelog)-MAX_TOPOLOGY_CHANGES:]
+	}
+}
+
+// channels leaving OPEN are alerted until they are open again, the other changes are sent once
+func notifyChanges(changes []TopologyChange) {
+	for _, change := range changes {
+		msg := fmt.Sprintf("topology changed, %s", change.String())
+		logger.Info(msg)
+
+		alertKey := alert.Key{
+			ChainId:             change.ChainId,
+			CounterpartyChainId: change.CounterpartyChainId,
+			ClientId:            change.ClientId,
+			ChannelId:           change.ChannelId,
+			PortId:              change.PortId,
+
+			Condition: ALERT_TOPOLOGY_CHANGE,
+		}
+		if !change.isChannel() {
+			alert.Send(alertKey, change.severity(), msg)
+			continue
+		}
+
+		alertKey.Condition = ALERT_CHANNEL_STATE
+		switch {
+		case change.Type == TOPOLOGY_CHANNEL_OPENED:
+			alert.Resolve(alertKey, msg)
+
+			alertKey.Condition = ALERT_TOPOLOGY_CHANGE
+			alert.Send(alertKey, alert.INFO, msg)
+		case change.Type == TOPOLOGY_CHANNEL_CLOSED:
+			alert.Fire(alertKey, alert.WARNING, msg)
+		case change.State == STATE_OPEN:
+			alert.Resolve(alertKey, msg)
+		case change.PrevState == STATE_OPEN:
+			alert.Fire(alertKey, alert.WARNING, msg)
+		}
+	}
+}
+
+// refresh ibc info in place, and start or stop only the trackers of changed channels
+func (app *App) refreshIBCInfo(ctx context.Context) error {
+	err := app.initIBCInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	return app.syncIBCPacketTrackers(ctx)
+}
+
+// replace the clients of the chain with the discovered ones,
+// they inherit the state of the previous clients and the changes between them are recorded
+func (app *App) replaceClients(chainId string, clients Clients) {
+	var changes []TopologyChange
+	app.updateStore(func() {
+		prev, ok := app.Store.IBCInfo[chainId]
+		// nothing to compare with on the first discovery
+		if ok {
+			changes = clients.inherit(chainId, prev, time.Now().UTC())
+		}
+
+		app.Store.IBCInfo[chainId] = clients
+		app.Store.record(changes)
+	})
+
+	notifyChanges(changes)
+}
+
+// inherit the state of prev and return the changes from prev, app.storeMutex should be held
+func (clients Clients) inherit(chainId string, prev Clients, now time.Time) []TopologyChange {
+	var changes []TopologyChange
+
+	for clientId, client := range clients {
+		prevClient, ok := prev[clientId]
+		if !ok {
+			changes = append(changes, TopologyChange{
+				Time: now,
+				Type: TOPOLOGY_CLIENT_ADDED,
+
+				ChainId:             chainId,
+				CounterpartyChainId: client.ChainId,
+				ClientId:            clientId,
+			})
+			changes = append(changes, diffPaths(chainId, clientId, client, nil, now)...)
+
+			continue
+		}
+
+		// kept as it is, e.g. the counterparty client failed to be discovered
+		if prevClient == client {
+			continue
+		}
+
+		client.inherit(prevClient)
+		changes = append(changes, diffPaths(chainId, clientId, client, prevClient, now)...)
+	}
+
+	for clientId, prevClient := range prev {
+		if _, ok := clients[clientId]; ok {
+			continue
+		}
+
+		changes = append(changes, diffPaths(chainId, clientId, nil, prevClient, now)...)
+		changes = append(changes, TopologyChange{
+			Time: now,
+			Type: TOPOLOGY_CLIENT_REMOVED,
+
+			ChainId:             chainId,
+			CounterpartyChainId: prevClient.ChainId,
+			ClientId:            clientId,
+		})
+	}
+
+	return changes
+}
+
+// keep health, unrelayed packets and trackers of the same client discovered again
+func (client *Client) inherit(prev *Client) {
+	active := client.Status == exported.Active.String()
+
+	client.Health = client.Health && prev.Health
+	// the discovered status is kept, app.checkClientsHealth alerts on the transition from the checked one
+	client.CheckedStatus = prev.CheckedStatus
+	client.ClientUpdated = prev.ClientUpdated
+	client.UpdateInterval = prev.UpdateInterval
+	client.ExpiryForecast = prev.ExpiryForecast
+	client.Degraded = prev.Degraded
+
+	// paths of non-active clients are not discovered, so the known ones are kept
+	if !active && len(client.Connections) == 0 && client.V2 == nil {
+		client.Connections = prev.Connections
+		client.ConnectionStates = prev.ConnectionStates
+		client.V2 = prev.V2
+		return
+	}
+
+	for connectionId, channels := range client.Connections {
+		for channelId, channel := range channels {
+			prevChannel, ok := prev.Connections[connectionId][channelId]
+			if !ok || prevChannel == nil {
+				continue
+			}
+
+			channel.inherit(prevChannel)
+		}
+	}
+	if client.V2 != nil && prev.V2 != nil {
+		client.V2.inherit(prev.V2)
+	}
+}
+
+func (channel *Channel) inherit(prev *Channel) {
+	channel.Unrelayed = prev.Unrelayed
+	// the tracker of a closed channel is stopped by app.syncIBCPacketTrackers
+	if channel.GetState() != STATE_CLOSED {
+		channel.IBCPacketTracker = prev.IBCPacketTracker
+	}
+
+	// still flushing since the previous discovery
+	if channel.isUpgrading() && prev.isUpgrading() && !prev.FlushingSince.IsZero() {
+		channel.FlushingSince = prev.FlushingSince
+	}
+}
+
+// connections and channels opened or closed between the clients, either of them can be nil
+func diffPaths(chainId, clientId string, client, prevClient *Client, now time.Time) []TopologyChange {
+	var changes []TopologyChange
+
+	var connections, prevConnections Connections
+	var paths, prevPaths []ChannelPath
+	var counterpartyChainId string
+	if client != nil {
+		connections, paths, counterpartyChainId = client.Connections, client.Paths(clientId), client.ChainId
+	}
+	if prevClient != nil {
+		prevConnections, prevPaths, counterpartyChainId = prevClient.Connections, prevClient.Paths(clientId), prevClient.ChainId
+	}
+
+	change := func(changeType, connectionId, channelId, portId string) TopologyChange {
+		return TopologyChange{
+			Time: now,
+			Type: changeType,
+
+			ChainId:             chainId,
+			CounterpartyChainId: counterpartyChainId,
+			ClientId:            clientId,
+			ConnectionId:        connectionId,
+			ChannelId:           channelId,
+			PortId:              portId,
+		}
+	}
+
+	for connectionId := range connections {
+		if _, ok := prevConnections[connectionId]; !ok {
+			changes = append(changes, change(TOPOLOGY_CONNECTION_OPENED, connectionId, "", ""))
+		}
+	}
+	for _, path := range paths {
+		prevPath, ok := findPath(prevPaths, path)
+		if !ok {
+			// channels in handshake are recorded without being opened
+			if path.Channel.canRelay() {
+				opened := change(TOPOLOGY_CHANNEL_OPENED, path.ConnectionId, path.ChannelId, path.Channel.PortId)
+				opened.State = path.Channel.GetState()
+				changes = append(changes, opened)
+			}
+			continue
+		}
+
+		base := change("", path.ConnectionId, path.ChannelId, path.Channel.PortId)
+		changes = append(changes, channelStateChanges(base, prevPath.Channel.GetState(), path.Channel.GetState())...)
+	}
+	for _, path := range prevPaths {
+		if _, ok := findPath(paths, path); !ok && path.Channel.canRelay() {
+			closed := change(TOPOLOGY_CHANNEL_CLOSED, path.ConnectionId, path.ChannelId, path.Channel.PortId)
+			closed.PrevState = path.Channel.GetState()
+			changes = append(changes, closed)
+		}
+	}
+	for connectionId := range prevConnections {
+		if _, ok := connections[connectionId]; !ok {
+			changes = append(changes, change(TOPOLOGY_CONNECTION_CLOSED, connectionId, "", ""))
+		}
+	}
+
+	return changes
+}
+
+func findPath(paths []ChannelPath, path ChannelPath) (ChannelPath, bool) {
+	for _, p := range paths {
+		if p.ConnectionId == path.ConnectionId && p.ChannelId == path.ChannelId && p.Channel.PortId == path.Channel.PortId {
+			return p, true
+		}
+	}
+	return ChannelPath{}, false
+}
+
+// change of the channel from prevState to state, the type of base is decided with the states
+func channelStateChanges(base TopologyChange, prevState, state string) []TopologyChange {
+	if prevState == state {
+		return nil
+	}
+
+	base.PrevState = prevState
+	base.State = state
+
+	wasRelayable := (&Channel{State: prevState}).canRelay()
+	relayable := (&Channel{State: state}).canRelay()
+	switch {
+	case !wasRelayable && relayable:
+		base.Type = TOPOLOGY_CHANNEL_OPENED
+	case wasRelayable && !relayable:
+		base.Type = TOPOLOGY_CHANNEL_CLOSED
+	default:
+		base.Type = TOPOLOGY_CHANNEL_STATE_CHANGED
+	}
+
+	return []TopologyChange{base}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/dlvlabs/ibcmon/client/grpc"
+	"github.com/dlvlabs/ibcmon/client/retry"
+	"github.com/dlvlabs/ibcmon/client/rpc"
+)
+
+// the refresh loop replaces clients and adds counterparty endpoints while the other jobs read them, run with -race
+func TestRefreshIBCInfoConcurrentAccess(t *testing.T) {
+	app := newTestApp()
+	ibcPacketTracker := newTestTracker()
+	app.replaceClients("src-1", newTestClients(ibcPacketTracker))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			clients := newTestClients(nil)
+			clients["07-tendermint-0"].Connections["connection-0"]["channel-0"].State = []string{STATE_OPEN, STATE_FLUSHING}[i%2]
+			app.replaceClients("src-1", clients)
+
+			client := clients["07-tendermint-0"]
+			app.updateStore(func() { client.Unmonitored = i%3 == 0 })
+
+			// same as app.resolveCounterparty
+			chainId := fmt.Sprintf("counterparty-%d", i)
+			rpcClient, err := rpc.New([]string{"http://localhost:26657"}, retry.DefaultPolicy())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			grpcClient := grpc.New([]string{"localhost:9090"}, false, retry.DefaultPolicy())
+
+			app.endpointsMutex.Lock()
+			app.rpcs[chainId] = rpcClient
+			app.grpcs[chainId] = grpcClient
+			app.endpointsMutex.Unlock()
+		}
+	}()
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				// same as app.syncIBCPacketTrackers and app.checkUnrelayedPackets
+				for chainId, clients := range app.ibcInfo() {
+					for clientId, client := range clients {
+						if !app.isMonitored(client) {
+							continue
+						}
+
+						for _, path := range client.Paths(clientId) {
+							_ = app.canRelay(path.Channel)
+						}
+						_ = app.getRPC(chainId)
+						_ = app.getGRPC(client.ChainId)
+					}
+				}
+				_ = app.getGRPC(fmt.Sprintf("counterparty-%d", i))
+				_ = app.EndpointStatuses()
+
+				_ = app.Store.Snapshot()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	channel := app.ibcInfo()["src-1"]["07-tendermint-0"].Connections["connection-0"]["channel-0"]
+	if channel.IBCPacketTracker != ibcPacketTracker {
+		t.Fatal("tracker is not inherited by the refreshed channel")
+	}
+	if app.getGRPC("counterparty-99") == nil {
+		t.Fatal("resolved counterparty endpoints are not found")
+	}
+}
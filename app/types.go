@@ -2,14 +2,22 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dlvlabs/ibcmon/alert"
 	"github.com/dlvlabs/ibcmon/client/grpc"
+	"github.com/dlvlabs/ibcmon/client/retry"
 	"github.com/dlvlabs/ibcmon/client/rpc"
+	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/dlvlabs/ibcmon/registry"
+	"github.com/dlvlabs/ibcmon/state"
+	"github.com/pkg/errors"
 
 	codecTypes "github.com/cosmos/cosmos-sdk/codec/types"
-	tendermint "github.com/cosmos/ibc-go/v10/modules/light-clients/07-tendermint"
 )
 
 type (
@@ -17,13 +25,30 @@ type (
 		cfg Config
 		cdc codecTypes.InterfaceRegistry
 
-		rpcs RPCs
+		// endpointsMutex guards adding rpcs and grpcs of the counterparties found in chain registry
+		endpointsMutex sync.RWMutex
+		rpcs           RPCs
 
 		grpcsMutex sync.Mutex
 		grpcs      GRPCs
 
 		storeMutex sync.Mutex
 		Store      Store
+		// set by trackers, which are updated with their own locks, and published by app.runSnapshotPublisher
+		trackersChanged atomic.Bool
+
+		// trackerKey => running tracker, guarded by trackersMutex
+		trackersMutex sync.Mutex
+		trackers      map[string]*runningTracker
+		// only in PACKET_TRACKING_EVENT mode
+		subscribers packetSubscribers
+		// trackerKey => state loaded on startup, consumed by the first app.syncIBCPacketTrackers, guarded by trackersMutex
+		restored map[string]TrackerState
+
+		state state.StateStore
+
+		// nil if chain registry is not configured
+		registry *registry.Registry
 	}
 
 	// chainId => rpc | grpc client
@@ -33,42 +58,115 @@ type (
 
 type (
 	Config struct {
-		General General `toml:"general"`
-		TG      TG      `toml:"tg"`
-		Rule    Rule    `toml:"rule"`
+		General   General         `toml:"general"`
+		TG        TG              `toml:"tg"`
+		Slack     Slack           `toml:"slack"`
+		Discord   Discord         `toml:"discord"`
+		PagerDuty PagerDuty       `toml:"pagerduty"`
+		Webhook   Webhook         `toml:"webhook"`
+		Alert     Alert           `toml:"alert"`
+		Silences  []alert.Silence `toml:"silences"`
+		Rule      Rule            `toml:"rule"`
+		State     StateConfig     `toml:"state"`
+		// retry policy of rpc and grpc requests
+		Retry retry.Policy `toml:"retry"`
 
-		BaseChain Endpoints `toml:"base_chain"`
+		ChainRegistry ChainRegistry `toml:"chain_registry"`
+
+		// base_chain is kept for the config files with a single base chain
+		BaseChain  Endpoints   `toml:"base_chain"`
+		BaseChains []Endpoints `toml:"base_chains"`
 
 		// chainId => endpoint info
 		Counterparties map[string]Endpoints `toml:"counterparties"`
 	}
 
 	General struct {
-		baseChainId string
+		baseChainIds []string
 
 		LogLevel   string `toml:"log_level"`
 		ListenPort int    `toml:"listen_port"`
+		// bearer token required to create and delete silences, they are disabled if empty
+		AdminToken string `toml:"admin_token"`
 
 		IbcInfoUpdateInterval  time.Duration `toml:"ibc_info_update_interval"`
 		ClientCheckInterval    time.Duration `toml:"client_check_interval"`
 		PacketTrackingInterval time.Duration `toml:"packet_tracking_interval"`
+		// "polling" or "event"
+		PacketTrackingMode string `toml:"packet_tracking_mode"`
+		// 0 disables checking unrelayed packets
+		UnrelayedCheckInterval time.Duration `toml:"unrelayed_check_interval"`
+		// "error" or "unmonitored", handling of the counterparties without endpoints
+		UnknownCounterparty string `toml:"unknown_counterparty"`
+		// interval of measuring latency of every endpoint
+		EndpointCheckInterval time.Duration `toml:"endpoint_check_interval"`
+		// interval of checking channel states between ibc info updates
+		ChannelCheckInterval time.Duration `toml:"channel_check_interval"`
+	}
+	Alert struct {
+		// alerts raised within this duration are sent as one message
+		GroupWait time.Duration `toml:"group_wait"`
+		// firing alerts are sent again after this duration, 0 means never
+		ReminderInterval time.Duration `toml:"reminder_interval"`
 	}
+	// severity: the lowest severity to be sent, "info", "warning", "error" or "critical"
 	TG struct {
-		Enable bool   `toml:"enable"`
-		Token  string `toml:"token"`
-		ChatID string `toml:"chat_id"`
+		Enable   bool   `toml:"enable"`
+		Token    string `toml:"token"`
+		ChatID   string `toml:"chat_id"`
+		Severity string `toml:"severity"`
+	}
+	Slack struct {
+		Enable     bool   `toml:"enable"`
+		WebhookURL string `toml:"webhook_url"`
+		Severity   string `toml:"severity"`
+	}
+	Discord struct {
+		Enable     bool   `toml:"enable"`
+		WebhookURL string `toml:"webhook_url"`
+		Severity   string `toml:"severity"`
+	}
+	PagerDuty struct {
+		Enable     bool   `toml:"enable"`
+		RoutingKey string `toml:"routing_key"`
+		Severity   string `toml:"severity"`
+	}
+	Webhook struct {
+		Enable   bool   `toml:"enable"`
+		URL      string `toml:"url"`
+		Severity string `toml:"severity"`
 	}
 	Rule struct {
 		ClientExpiredWarningTime time.Duration `toml:"client_expired_warning_time"`
 		ConsecutiveMissedPackets uint64        `toml:"consecutive_missed_packets"`
+		// unhealthy if the oldest unrelayed packet stays longer than this
+		UnrelayedPacketWarningTime time.Duration `toml:"unrelayed_packet_warning_time"`
+		// warn if a channel upgrade stays FLUSHING or FLUSHCOMPLETE longer than this
+		ChannelFlushingWarningTime time.Duration `toml:"channel_flushing_warning_time"`
+		// warn if a timed out packet is not timed out on source longer than this, its funds stay escrowed
+		TimeoutPacketWarningTime time.Duration `toml:"timeout_packet_warning_time"`
+	}
+	StateConfig struct {
+		// "memory", "bolt" or "file"
+		Backend      string        `toml:"backend"`
+		Path         string        `toml:"path"`
+		SaveInterval time.Duration `toml:"save_interval"`
+	}
+	ChainRegistry struct {
+		// local clone or snapshot of the chain registry, disabled if empty
+		Path string `toml:"path"`
 	}
 	Endpoints struct {
 		GRPC    GRPC   `toml:"grpc"`
 		RPCAddr string `toml:"rpc_addr"`
+		// fallback endpoints of rpc_addr
+		RPCAddrs []string `toml:"rpc_addrs"`
 	}
 	GRPC struct {
 		Addr    string `toml:"addr"`
 		TLSConn bool   `toml:"tls_conn"`
+		// fallback endpoints of addr, with the same tls_conn
+		Addrs []string `toml:"addrs"`
 	}
 )
 
@@ -76,7 +174,15 @@ type (
 	Store struct {
 		Updated time.Time
 
-		IBCInfo IBCInfo
+		// every chain in IBCInfo is either one of base chains or a counterparty of them
+		BaseChainIds []string
+		IBCInfo      IBCInfo
+
+		// latest topology changes found by refreshing ibc info
+		Changelog []TopologyChange
+
+		// read model of the server, see Store.Snapshot
+		snapshot atomic.Pointer[Snapshot]
 	}
 )
 
@@ -84,36 +190,95 @@ func NewApp(ctx context.Context, cfg Config) (*App, error) {
 	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
 	defer cancel()
 
-	bcGRPC := grpc.New(cfg.BaseChain.GRPC.Addr, cfg.BaseChain.GRPC.TLSConn)
-	bcGRPC.Connect()
-	defer bcGRPC.Terminate()
+	baseChains := cfg.BaseChains
+	if len(cfg.BaseChain.grpcAddrs()) != 0 {
+		baseChains = append([]Endpoints{cfg.BaseChain}, baseChains...)
+	}
+	if len(baseChains) == 0 {
+		return nil, errors.New("missing base chains in config file")
+	}
 
-	bcChainId, err := bcGRPC.GetChainId(ctx)
-	if err != nil {
-		return nil, err
+	switch cfg.General.UnknownCounterparty {
+	case "", UNKNOWN_COUNTERPARTY_ERROR, UNKNOWN_COUNTERPARTY_UNMONITORED:
+	default:
+		msg := fmt.Sprintf("unknown unknown_counterparty in config file: %s", cfg.General.UnknownCounterparty)
+		return nil, errors.New(msg)
 	}
 
-	cfg.General.baseChainId = bcChainId
+	if cfg.Retry == (retry.Policy{}) {
+		cfg.Retry = retry.DefaultPolicy()
+	} else {
+		cfg.Retry = cfg.Retry.WithDefaults()
+	}
 
 	rpcs := make(RPCs)
 	grpcs := make(GRPCs)
 
-	rpcs[bcChainId], err = rpc.New(cfg.BaseChain.RPCAddr)
+	for _, endpoints := range baseChains {
+		bcChainId, err := getChainId(ctx, endpoints, cfg.Retry)
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.Contains(cfg.General.baseChainIds, bcChainId) {
+			msg := fmt.Sprintf("duplicated base chain in config file: %s", bcChainId)
+			logger.Warn(msg)
+
+			continue
+		}
+		cfg.General.baseChainIds = append(cfg.General.baseChainIds, bcChainId)
+
+		rpcs[bcChainId], err = rpc.New(endpoints.rpcAddrs(), cfg.Retry)
+		if err != nil {
+			return nil, err
+		}
+		grpcs[bcChainId] = grpc.New(endpoints.grpcAddrs(), endpoints.GRPC.TLSConn, cfg.Retry)
+	}
+
+	var err error
+	for chainId, endpoints := range cfg.Counterparties {
+		// base chains are connected with their own endpoints
+		if _, ok := grpcs[chainId]; ok {
+			continue
+		}
+
+		rpcs[chainId], err = rpc.New(endpoints.rpcAddrs(), cfg.Retry)
+		if err != nil {
+			return nil, err
+		}
+		grpcs[chainId] = grpc.New(endpoints.grpcAddrs(), endpoints.GRPC.TLSConn, cfg.Retry)
+	}
+
+	stateStore, err := state.New(cfg.State.Backend, cfg.State.Path)
 	if err != nil {
 		return nil, err
 	}
-	grpcs[bcChainId] = grpc.New(cfg.BaseChain.GRPC.Addr, cfg.BaseChain.GRPC.TLSConn)
+	if cfg.State.SaveInterval == 0 {
+		cfg.State.SaveInterval = 1 * time.Minute
+	}
+	if cfg.General.EndpointCheckInterval == 0 {
+		cfg.General.EndpointCheckInterval = 1 * time.Minute
+	}
+	if cfg.General.ChannelCheckInterval == 0 {
+		cfg.General.ChannelCheckInterval = 10 * time.Minute
+	}
+	if cfg.Rule.ChannelFlushingWarningTime == 0 {
+		cfg.Rule.ChannelFlushingWarningTime = 1 * time.Hour
+	}
+	if cfg.Rule.TimeoutPacketWarningTime == 0 {
+		cfg.Rule.TimeoutPacketWarningTime = 1 * time.Hour
+	}
 
-	for chainId, endpoints := range cfg.Counterparties {
-		rpcs[chainId], err = rpc.New(endpoints.RPCAddr)
+	var chainRegistry *registry.Registry
+	if cfg.ChainRegistry.Path != "" {
+		chainRegistry, err = registry.New(cfg.ChainRegistry.Path)
 		if err != nil {
 			return nil, err
 		}
-		grpcs[chainId] = grpc.New(endpoints.GRPC.Addr, endpoints.GRPC.TLSConn)
 	}
 
 	cdc := codecTypes.NewInterfaceRegistry()
-	tendermint.RegisterInterfaces(cdc)
+	registerLightClients(cdc)
 
 	app := &App{
 		cfg: cfg,
@@ -123,8 +288,57 @@ func NewApp(ctx context.Context, cfg Config) (*App, error) {
 		grpcs: grpcs,
 
 		Store: Store{
-			IBCInfo: make(IBCInfo),
+			BaseChainIds: cfg.General.baseChainIds,
+			IBCInfo:      make(IBCInfo),
 		},
+
+		trackers:    make(map[string]*runningTracker),
+		subscribers: make(packetSubscribers),
+
+		state: stateStore,
+
+		registry: chainRegistry,
+	}
+
+	// serve the persisted ibc info until the first discovery is done
+	prev, err := app.loadState()
+	if err != nil {
+		return nil, err
 	}
+	if prev != nil {
+		app.restored = prev.Trackers
+	}
+	if prev != nil && prev.IBCInfo != nil {
+		app.Store.IBCInfo = prev.IBCInfo
+
+		msg := fmt.Sprintf("restored state saved at %s", prev.Saved)
+		logger.Info(msg)
+	}
+	app.publish()
+
 	return app, nil
 }
+
+func getChainId(ctx context.Context, endpoints Endpoints, retry retry.Policy) (string, error) {
+	bcGRPC := grpc.New(endpoints.grpcAddrs(), endpoints.GRPC.TLSConn, retry)
+	err := bcGRPC.Connect()
+	if err != nil {
+		return "", err
+	}
+	defer bcGRPC.Terminate()
+
+	return bcGRPC.GetChainId(ctx)
+}
+
+func (app *App) StateStore() state.StateStore {
+	return app.state
+}
+
+func (app *App) Close() error {
+	err := app.saveState()
+	if err != nil {
+		return err
+	}
+
+	return app.state.Close()
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/alert"
+	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+type Unrelayed struct {
+	Updated time.Time
+
+	Health bool
+
+	// sent on source but not received on destination
+	UnreceivedPackets []uint64
+	// received on destination but the acknowledgement is not relayed to source
+	UnreceivedAcks []uint64
+
+	// the oldest unrelayed sequence, 0 if every packet is relayed
+	OldestSequence uint64
+	// when OldestSequence is observed first
+	OldestSince time.Time
+
+	// set while checking fails, the values above are of the last success
+	Degraded *Degradation
+}
+
+// cross-reference packet commitments and acknowledgements between source and counterparty,
+// this doesn't depend on tx indexing of rpc nodes
+func (app *App) checkUnrelayedPackets(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	defer cancel()
+
+	err := app.connectGRPCs()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = app.terminateGRPCs()
+		if err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	var g errgroup.Group
+
+	for chainId, clients := range app.ibcInfo() {
+		for clientId, client := range clients {
+			if !app.isMonitored(client) {
+				continue
+			}
+
+			for _, path := range client.Paths(clientId) {
+				channelId, channel := path.ChannelId, path.Channel
+				if !app.canRelay(channel) {
+					continue
+				}
+
+				src := Chain{
+					grpc:      app.getGRPC(chainId),
+					ChainId:   chainId,
+					ChannelId: channelId,
+					PortId:    channel.PortId,
+					V2:        channel.V2,
+				}
+				dst := Chain{
+					grpc:      app.getGRPC(client.ChainId),
+					ChainId:   client.ChainId,
+					ChannelId: channel.Counterparty.ChannelId,
+					PortId:    channel.Counterparty.PortId,
+					V2:        channel.V2,
+				}
+
+				g.Go(func() error {
+					path := fmt.Sprintf("%s => %s", src.String(), dst.String())
+
+					// failure of a path doesn't stop checking the others
+					unrelayed, err := getUnrelayed(ctx, src, dst)
+					if err != nil {
+						logger.Error(errors.Wrapf(err, "failed to check unrelayed packets: %s", path))
+						app.updateStore(func() { channel.Unrelayed = channel.Unrelayed.degrade(err) })
+
+						return nil
+					}
+
+					var warning string
+					app.updateStore(func() {
+						warning = unrelayed.update(channel.Unrelayed, app.cfg.Rule.UnrelayedPacketWarningTime, path)
+						channel.Unrelayed = unrelayed
+					})
+
+					if warning != "" {
+						logger.Warn(warning)
+					}
+
+					alertKey := alert.Key{
+						ChainId:             chainId,
+						CounterpartyChainId: client.ChainId,
+						ClientId:            clientId,
+						ChannelId:           channelId,
+						PortId:              channel.PortId,
+
+						Condition: ALERT_UNRELAYED_PACKETS,
+					}
+					if !unrelayed.Health {
+						alert.Fire(alertKey, alert.WARNING, unrelayed.warning(path))
+					} else {
+						msg := fmt.Sprintf("stuck packets are relayed: %s", path)
+						alert.Resolve(alertKey, msg)
+					}
+
+					return nil
+				})
+			}
+		}
+	}
+
+	return g.Wait()
+}
+
+func getUnrelayed(ctx context.Context, src, dst Chain) (*Unrelayed, error) {
+	commitments, err := src.getPacketCommitments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unreceivedPackets, err := dst.getUnreceivedPackets(ctx, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	acks, err := dst.getPacketAcknowledgements(ctx, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	unreceivedAcks, err := src.getUnreceivedAcks(ctx, acks)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(unreceivedPackets)
+	slices.Sort(unreceivedAcks)
+
+	unrelayed := &Unrelayed{
+		Updated: time.Now().UTC(),
+
+		Health: true,
+
+		UnreceivedPackets: unreceivedPackets,
+		UnreceivedAcks:    unreceivedAcks,
+	}
+
+	if len(unreceivedPackets) > 0 {
+		unrelayed.OldestSequence = unreceivedPackets[0]
+	}
+	if len(unreceivedAcks) > 0 && (unrelayed.OldestSequence == 0 || unreceivedAcks[0] < unrelayed.OldestSequence) {
+		unrelayed.OldestSequence = unreceivedAcks[0]
+	}
+
+	return unrelayed, nil
+}
+
+// carry over when the oldest sequence is observed first and decide health,
+// return a warning message only when it becomes unhealthy
+func (unrelayed *Unrelayed) update(prev *Unrelayed, warningTime time.Duration, path string) string {
+	if unrelayed.OldestSequence == 0 {
+		if prev != nil && !prev.Health {
+			msg := fmt.Sprintf("every packet is relayed: %s", path)
+			logger.Info(msg)
+		}
+		return ""
+	}
+
+	unrelayed.OldestSince = unrelayed.Updated
+	if prev != nil && prev.OldestSequence == unrelayed.OldestSequence {
+		unrelayed.OldestSince = prev.OldestSince
+	}
+
+	if unrelayed.Updated.Sub(unrelayed.OldestSince) < warningTime {
+		return ""
+	}
+	unrelayed.Health = false
+
+	if prev != nil && !prev.Health {
+		return ""
+	}
+
+	return unrelayed.warning(path)
+}
+
+// keep the last result with the error
+func (unrelayed *Unrelayed) degrade(err error) *Unrelayed {
+	degraded := &Unrelayed{Health: true}
+	if unrelayed != nil {
+		*degraded = *unrelayed
+	}
+	degraded.Degraded = degraded.Degraded.update(err)
+
+	return degraded
+}
+
+func (unrelayed *Unrelayed) warning(path string) string {
+	return fmt.Sprintf(
+		"%d packets stuck, oldest sequence %d since %s: %s",
+		len(unrelayed.UnreceivedPackets)+len(unrelayed.UnreceivedAcks),
+		unrelayed.OldestSequence, unrelayed.OldestSince, path,
+	)
+}
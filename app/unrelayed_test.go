@@ -0,0 +1,81 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnrelayedUpdate(t *testing.T) {
+	updated := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	warningTime := 10 * time.Minute
+
+	tests := []struct {
+		name string
+
+		prev      *Unrelayed
+		unrelayed Unrelayed
+
+		expectedOldestSince time.Time
+		expectedHealth      bool
+		expectedWarning     bool
+	}{
+		{
+			name:           "every packet is relayed",
+			prev:           &Unrelayed{Health: false, OldestSequence: 3, OldestSince: updated.Add(-1 * time.Hour)},
+			unrelayed:      Unrelayed{Updated: updated, Health: true},
+			expectedHealth: true,
+		},
+		{
+			name:                "first observed",
+			unrelayed:           Unrelayed{Updated: updated, Health: true, UnreceivedPackets: []uint64{3}, OldestSequence: 3},
+			expectedOldestSince: updated,
+			expectedHealth:      true,
+		},
+		{
+			name:                "stuck shorter than warning time",
+			prev:                &Unrelayed{Health: true, OldestSequence: 3, OldestSince: updated.Add(-5 * time.Minute)},
+			unrelayed:           Unrelayed{Updated: updated, Health: true, UnreceivedPackets: []uint64{3}, OldestSequence: 3},
+			expectedOldestSince: updated.Add(-5 * time.Minute),
+			expectedHealth:      true,
+		},
+		{
+			name:                "stuck longer than warning time",
+			prev:                &Unrelayed{Health: true, OldestSequence: 3, OldestSince: updated.Add(-15 * time.Minute)},
+			unrelayed:           Unrelayed{Updated: updated, Health: true, UnreceivedPackets: []uint64{3}, OldestSequence: 3},
+			expectedOldestSince: updated.Add(-15 * time.Minute),
+			expectedHealth:      false,
+			expectedWarning:     true,
+		},
+		{
+			name:                "still stuck is warned once",
+			prev:                &Unrelayed{Health: false, OldestSequence: 3, OldestSince: updated.Add(-15 * time.Minute)},
+			unrelayed:           Unrelayed{Updated: updated, Health: true, UnreceivedAcks: []uint64{3}, OldestSequence: 3},
+			expectedOldestSince: updated.Add(-15 * time.Minute),
+			expectedHealth:      false,
+		},
+		{
+			name:                "oldest packet is relayed",
+			prev:                &Unrelayed{Health: false, OldestSequence: 3, OldestSince: updated.Add(-15 * time.Minute)},
+			unrelayed:           Unrelayed{Updated: updated, Health: true, UnreceivedPackets: []uint64{4}, OldestSequence: 4},
+			expectedOldestSince: updated,
+			expectedHealth:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			unrelayed := test.unrelayed
+			warning := unrelayed.update(test.prev, warningTime, "src-1 => dst-1")
+
+			if !unrelayed.OldestSince.Equal(test.expectedOldestSince) {
+				t.Fatalf("expected oldest since %s, got %s", test.expectedOldestSince, unrelayed.OldestSince)
+			}
+			if unrelayed.Health != test.expectedHealth {
+				t.Fatalf("expected health %t, got %t", test.expectedHealth, unrelayed.Health)
+			}
+			if (warning != "") != test.expectedWarning {
+				t.Fatalf("expected warning %t, got %q", test.expectedWarning, warning)
+			}
+		})
+	}
+}
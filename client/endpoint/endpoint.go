@@ -0,0 +1,177 @@
+package endpoint
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/logger"
+)
+
+// Pool keeps endpoints of a chain and selects the active one.
+// The healthy endpoint with the lowest latency is selected on health check,
+// and the active endpoint is switched to the next one on failure.
+type Pool struct {
+	mutex     sync.RWMutex
+	endpoints []*endpoint
+	active    int
+}
+
+type endpoint struct {
+	host string
+
+	// updated by health check
+	healthy bool
+	latency time.Duration
+
+	requests uint64
+	errors   uint64
+	// requests sent again after failing on this endpoint
+	retries uint64
+}
+
+type Status struct {
+	Host    string
+	Active  bool
+	Healthy bool
+	// latency of the last health check
+	Latency  time.Duration
+	Requests uint64
+	Errors   uint64
+	Retries  uint64
+}
+
+func NewPool(hosts []string) *Pool {
+	endpoints := make([]*endpoint, 0, len(hosts))
+	for _, host := range hosts {
+		// every endpoint is regarded as healthy until checked
+		endpoints = append(endpoints, &endpoint{host: host, healthy: true})
+	}
+
+	return &Pool{
+		endpoints: endpoints,
+	}
+}
+
+func (p *Pool) Len() int {
+	return len(p.endpoints)
+}
+
+func (p *Pool) Host(i int) string {
+	return p.endpoints[i].host
+}
+
+// index of the active endpoint
+func (p *Pool) Active() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.active
+}
+
+// Observe counts a request to the endpoint
+func (p *Pool) Observe(i int, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.endpoints[i].requests++
+	if err != nil {
+		p.endpoints[i].errors++
+	}
+}
+
+// Retry counts a retry of the request failed on the endpoint
+func (p *Pool) Retry(i int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.endpoints[i].retries++
+}
+
+// SetHealth records the result of health check of the endpoint
+func (p *Pool) SetHealth(i int, latency time.Duration, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.endpoints[i].healthy = err == nil
+	p.endpoints[i].latency = latency
+	if err != nil {
+		msg := fmt.Sprintf("unhealthy endpoint %s: %s", p.endpoints[i].host, err)
+		logger.Warn(msg)
+	}
+}
+
+// Select activates the healthy endpoint with the lowest latency,
+// the active endpoint is kept if every endpoint is unhealthy
+func (p *Pool) Select() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	selected := -1
+	for i, endpoint := range p.endpoints {
+		if !endpoint.healthy {
+			continue
+		}
+		if selected < 0 || endpoint.latency < p.endpoints[selected].latency {
+			selected = i
+		}
+	}
+
+	if selected < 0 || selected == p.active {
+		return
+	}
+
+	msg := fmt.Sprintf("switch endpoint %s => %s", p.endpoints[p.active].host, p.endpoints[selected].host)
+	logger.Info(msg)
+
+	p.active = selected
+}
+
+// Failover marks the endpoint unhealthy and activates the next healthy endpoint,
+// returns false if there's no other healthy endpoint
+func (p *Pool) Failover(i int, err error) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.endpoints[i].healthy = false
+
+	// already switched by another request
+	if p.active != i {
+		return true
+	}
+
+	for n := 1; n < len(p.endpoints); n++ {
+		next := (i + n) % len(p.endpoints)
+		if !p.endpoints[next].healthy {
+			continue
+		}
+
+		msg := fmt.Sprintf("failover endpoint %s => %s: %s", p.endpoints[i].host, p.endpoints[next].host, err)
+		logger.Warn(msg)
+
+		p.active = next
+		return true
+	}
+
+	return false
+}
+
+func (p *Pool) Statuses() []Status {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	statuses := make([]Status, 0, len(p.endpoints))
+	for i, endpoint := range p.endpoints {
+		statuses = append(statuses, Status{
+			Host:     endpoint.host,
+			Active:   i == p.active,
+			Healthy:  endpoint.healthy,
+			Latency:  endpoint.latency,
+			Requests: endpoint.requests,
+			Errors:   endpoint.errors,
+			Retries:  endpoint.retries,
+		})
+	}
+
+	return statuses
+}
@@ -0,0 +1,151 @@
+package endpoint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var errUnhealthy = errors.New("unhealthy")
+
+type health struct {
+	latency time.Duration
+	err     error
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name string
+
+		active int
+		checks []health
+
+		expected int
+	}{
+		{
+			name:     "lowest latency",
+			checks:   []health{{latency: 300 * time.Millisecond}, {latency: 100 * time.Millisecond}, {latency: 200 * time.Millisecond}},
+			expected: 1,
+		},
+		{
+			name:     "unhealthy endpoint is skipped",
+			checks:   []health{{latency: 300 * time.Millisecond}, {latency: 100 * time.Millisecond, err: errUnhealthy}, {latency: 200 * time.Millisecond}},
+			expected: 2,
+		},
+		{
+			name:     "active endpoint is kept if every endpoint is unhealthy",
+			active:   1,
+			checks:   []health{{err: errUnhealthy}, {err: errUnhealthy}, {err: errUnhealthy}},
+			expected: 1,
+		},
+		{
+			name:     "first endpoint wins a tie",
+			active:   2,
+			checks:   []health{{latency: 100 * time.Millisecond}, {latency: 100 * time.Millisecond}, {latency: 100 * time.Millisecond}},
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := NewPool([]string{"a", "b", "c"})
+			pool.active = test.active
+			for i, check := range test.checks {
+				pool.SetHealth(i, check.latency, check.err)
+			}
+
+			pool.Select()
+			if pool.Active() != test.expected {
+				t.Fatalf("expected %s, got %s", pool.Host(test.expected), pool.Host(pool.Active()))
+			}
+		})
+	}
+}
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name string
+
+		active    int
+		unhealthy []int
+		failed    int
+
+		expected   int
+		switchable bool
+	}{
+		{
+			name:       "next endpoint",
+			failed:     0,
+			expected:   1,
+			switchable: true,
+		},
+		{
+			name:       "unhealthy endpoint is skipped",
+			unhealthy:  []int{1},
+			failed:     0,
+			expected:   2,
+			switchable: true,
+		},
+		{
+			name:       "wraps around",
+			active:     2,
+			failed:     2,
+			expected:   0,
+			switchable: true,
+		},
+		{
+			name:       "no other healthy endpoint",
+			unhealthy:  []int{1, 2},
+			failed:     0,
+			expected:   0,
+			switchable: false,
+		},
+		{
+			name:       "already switched by another request",
+			active:     1,
+			failed:     0,
+			expected:   1,
+			switchable: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := NewPool([]string{"a", "b", "c"})
+			pool.active = test.active
+			for _, i := range test.unhealthy {
+				pool.SetHealth(i, 0, errUnhealthy)
+			}
+
+			switchable := pool.Failover(test.failed, errUnhealthy)
+			if switchable != test.switchable {
+				t.Fatalf("expected %t, got %t", test.switchable, switchable)
+			}
+			if pool.Active() != test.expected {
+				t.Fatalf("expected %s, got %s", pool.Host(test.expected), pool.Host(pool.Active()))
+			}
+			if pool.Statuses()[test.failed].Healthy {
+				t.Fatalf("failed endpoint %s should be unhealthy", pool.Host(test.failed))
+			}
+		})
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	pool := NewPool([]string{"a", "b"})
+	pool.Observe(0, nil)
+	pool.Observe(0, errUnhealthy)
+	pool.Failover(0, errUnhealthy)
+
+	statuses := pool.Statuses()
+	expected := []Status{
+		{Host: "a", Active: false, Healthy: false, Requests: 2, Errors: 1},
+		{Host: "b", Active: true, Healthy: true},
+	}
+	for i, status := range statuses {
+		if status != expected[i] {
+			t.Fatalf("expected %+v, got %+v", expected[i], status)
+		}
+	}
+}
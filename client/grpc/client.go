@@ -1,21 +1,27 @@
 package grpc
 
 import (
+	"context"
 	"crypto/tls"
+	"fmt"
+	"time"
 
+	"github.com/dlvlabs/ibcmon/client/endpoint"
 	"github.com/dlvlabs/ibcmon/logger"
 	"github.com/pkg/errors"
 
 	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
-	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
-	connectionTypes "github.com/cosmos/ibc-go/v10/modules/core/03-connection/types"
-	channelTypes "github.com/cosmos/ibc-go/v10/modules/core/04-channel/types"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-func (c *Client) Connect() error {
+// timeout of a health check request
+const HEALTH_CHECK_TIMEOUT = 5 * time.Second
+
+func (c *Client) dial(host string) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
 	if c.tlsConn {
 		opts = append(
@@ -30,18 +36,33 @@ func (c *Client) Connect() error {
 	}
 
 	conn, err := grpc.NewClient(
-		c.host,
+		host,
 		opts...,
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create grpc client")
+		return nil, errors.Wrap(err, "failed to create grpc client")
+	}
+
+	return conn, nil
+}
+
+func (c *Client) Connect() error {
+	conns := make([]*grpc.ClientConn, 0, c.pool.Len())
+	for i := range c.pool.Len() {
+		conn, err := c.dial(c.pool.Host(i))
+		if err != nil {
+			// close the connections already dialed
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+			return err
+		}
+		conns = append(conns, conn)
 	}
 
-	c.conn = conn
-	c.clientQueryClient = clientTypes.NewQueryClient(conn)
-	c.connectionQueryClient = connectionTypes.NewQueryClient(conn)
-	c.channelQueryClient = channelTypes.NewQueryClient(conn)
-	c.cmtServiceClient = cmtservice.NewServiceClient(conn)
+	c.connsMutex.Lock()
+	c.conns = conns
+	c.connsMutex.Unlock()
 
 	logger.Info("GRPC client created")
 
@@ -49,12 +70,133 @@ func (c *Client) Connect() error {
 }
 
 func (c *Client) Terminate() error {
-	err := c.conn.Close()
-	if err != nil {
-		return errors.Wrap(err, "failed to close grpc connection")
+	c.connsMutex.RLock()
+	defer c.connsMutex.RUnlock()
+
+	// every connection is closed even if some of them fail
+	var firstErr error
+	for _, conn := range c.conns {
+		err := conn.Close()
+		if err != nil && firstErr == nil {
+			firstErr = errors.Wrap(err, "failed to close grpc connection")
+		}
+	}
+	if firstErr != nil {
+		return firstErr
 	}
 
 	logger.Info("GRPC connection terminated")
 
 	return nil
 }
+
+// Invoke implements grpc.ClientConnInterface,
+// the request is sent again through the next endpoint if the active endpoint is unavailable,
+// and retried with the retry policy if every endpoint fails
+func (c *Client) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	c.connsMutex.RLock()
+	defer c.connsMutex.RUnlock()
+
+	if len(c.conns) == 0 {
+		return errors.New("grpc client is not connected")
+	}
+
+	var failed int
+	onRetry := func(attempt uint, err error) {
+		c.pool.Retry(failed)
+
+		msg := fmt.Sprintf("Retrying(attempt %d) %s: %s", attempt, method, err)
+		logger.Debug(msg)
+	}
+
+	return c.retry.Do(ctx, isRetryable, onRetry, func() error {
+		var err error
+		for range c.pool.Len() {
+			i := c.pool.Active()
+			err = c.conns[i].Invoke(ctx, method, args, reply, opts...)
+			c.pool.Observe(i, err)
+			if err != nil {
+				failed = i
+			}
+
+			if !isEndpointFailure(ctx, err) {
+				return err
+			}
+			if !c.pool.Failover(i, err) {
+				return err
+			}
+		}
+
+		return err
+	})
+}
+
+// NewStream implements grpc.ClientConnInterface, streams are not failed over
+func (c *Client) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	c.connsMutex.RLock()
+	defer c.connsMutex.RUnlock()
+
+	if len(c.conns) == 0 {
+		return nil, errors.New("grpc client is not connected")
+	}
+
+	return c.conns[c.pool.Active()].NewStream(ctx, desc, method, opts...)
+}
+
+// CheckEndpoints measures latency of every endpoint and activates the fastest one
+func (c *Client) CheckEndpoints(ctx context.Context) {
+	for i := range c.pool.Len() {
+		latency, err := c.checkEndpoint(ctx, c.pool.Host(i))
+		c.pool.SetHealth(i, latency, err)
+	}
+
+	c.pool.Select()
+}
+
+func (c *Client) checkEndpoint(ctx context.Context, host string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, HEALTH_CHECK_TIMEOUT)
+	defer cancel()
+
+	// health check uses its own connection, so it doesn't depend on Connect
+	conn, err := c.dial(host)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	_, err = cmtservice.NewServiceClient(conn).GetNodeInfo(ctx, &cmtservice.GetNodeInfoRequest{})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get node info")
+	}
+
+	return time.Since(start), nil
+}
+
+func (c *Client) EndpointStatuses() []endpoint.Status {
+	return c.pool.Statuses()
+}
+
+// errors of the endpoint itself, not of the request
+func isEndpointFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// temporary errors, the others such as NotFound and Unimplemented are permanent
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
@@ -11,7 +11,6 @@ import (
 	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
 	connectionTypes "github.com/cosmos/ibc-go/v10/modules/core/03-connection/types"
 	channelTypes "github.com/cosmos/ibc-go/v10/modules/core/04-channel/types"
-	"github.com/cosmos/ibc-go/v10/modules/core/exported"
 )
 
 func (c *Client) GetChainId(ctx context.Context) (string, error) {
@@ -66,7 +65,8 @@ func (c *Client) GetClientState(ctx context.Context, clientId string) (*codecTyp
 	return resp.ClientState, nil
 }
 
-func (c *Client) GetClientStatus(ctx context.Context, clientId string) (bool, error) {
+// returns one of exported.Status: "Active", "Expired", "Frozen", "Unknown" or "Unauthorized"
+func (c *Client) GetClientStatus(ctx context.Context, clientId string) (string, error) {
 	resp, err := c.clientQueryClient.ClientStatus(
 		ctx,
 		&clientTypes.QueryClientStatusRequest{
@@ -74,13 +74,10 @@ func (c *Client) GetClientStatus(ctx context.Context, clientId string) (bool, er
 		},
 	)
 	if err != nil {
-		return false, errors.Wrapf(err, "failed to get client status for client: %s", clientId)
+		return "", errors.Wrapf(err, "failed to get client status for client: %s", clientId)
 	}
 
-	if exported.Active.String() != resp.Status {
-		return false, nil
-	}
-	return true, nil
+	return resp.Status, nil
 }
 
 func (c *Client) GetClientConnections(ctx context.Context, clientId string) ([]string, error) {
@@ -138,6 +135,21 @@ func (c *Client) GetConnectionChannels(ctx context.Context, connectionId string)
 	return channelStates, nil
 }
 
+func (c *Client) GetChannel(ctx context.Context, channelId, portId string) (*channelTypes.Channel, error) {
+	resp, err := c.channelQueryClient.Channel(
+		ctx,
+		&channelTypes.QueryChannelRequest{
+			ChannelId: channelId,
+			PortId:    portId,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get channel: %s", channelId)
+	}
+
+	return resp.Channel, nil
+}
+
 func (c *Client) GetConsensusState(
 	ctx context.Context,
 	clientId string, revisionNumber uint64,
@@ -172,3 +184,112 @@ func (c *Client) GetNextSequenceSend(ctx context.Context, channelId, portId stri
 
 	return resp.NextSequenceSend, nil
 }
+
+// return sequences of packets which are sent but not acknowledged or timed out yet
+func (c *Client) GetPacketCommitments(ctx context.Context, channelId, portId string) ([]uint64, error) {
+	var sequences []uint64
+
+	page := &query.PageRequest{}
+	for {
+		resp, err := c.channelQueryClient.PacketCommitments(
+			ctx,
+			&channelTypes.QueryPacketCommitmentsRequest{
+				PortId:     portId,
+				ChannelId:  channelId,
+				Pagination: page,
+			},
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get packet commitments for channel: %s", channelId)
+		}
+
+		for _, commitment := range resp.Commitments {
+			sequences = append(sequences, commitment.Sequence)
+		}
+
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			break
+		}
+		page.Key = resp.Pagination.NextKey
+	}
+
+	return sequences, nil
+}
+
+// return sequences of packets which are not received on this chain among the given commitments of counterparty
+func (c *Client) GetUnreceivedPackets(ctx context.Context, channelId, portId string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+
+	resp, err := c.channelQueryClient.UnreceivedPackets(
+		ctx,
+		&channelTypes.QueryUnreceivedPacketsRequest{
+			PortId:                    portId,
+			ChannelId:                 channelId,
+			PacketCommitmentSequences: sequences,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get unreceived packets for channel: %s", channelId)
+	}
+
+	return resp.Sequences, nil
+}
+
+// return sequences of acknowledgements written on this chain among the given commitments of counterparty
+func (c *Client) GetPacketAcknowledgements(ctx context.Context, channelId, portId string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+
+	var ackSequences []uint64
+
+	page := &query.PageRequest{}
+	for {
+		resp, err := c.channelQueryClient.PacketAcknowledgements(
+			ctx,
+			&channelTypes.QueryPacketAcknowledgementsRequest{
+				PortId:                    portId,
+				ChannelId:                 channelId,
+				Pagination:                page,
+				PacketCommitmentSequences: sequences,
+			},
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get packet acknowledgements for channel: %s", channelId)
+		}
+
+		for _, ack := range resp.Acknowledgements {
+			ackSequences = append(ackSequences, ack.Sequence)
+		}
+
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			break
+		}
+		page.Key = resp.Pagination.NextKey
+	}
+
+	return ackSequences, nil
+}
+
+// return sequences of acknowledgements which are not relayed to this chain among the given acknowledgements of counterparty
+func (c *Client) GetUnreceivedAcks(ctx context.Context, channelId, portId string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+
+	resp, err := c.channelQueryClient.UnreceivedAcks(
+		ctx,
+		&channelTypes.QueryUnreceivedAcksRequest{
+			PortId:             portId,
+			ChannelId:          channelId,
+			PacketAckSequences: sequences,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get unreceived acks for channel: %s", channelId)
+	}
+
+	return resp.Sequences, nil
+}
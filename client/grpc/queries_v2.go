@@ -0,0 +1,156 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+	clientV2Types "github.com/cosmos/ibc-go/v10/modules/core/02-client/v2/types"
+	channelV2Types "github.com/cosmos/ibc-go/v10/modules/core/04-channel/v2/types"
+)
+
+// IBC v2 routes packets from client to client, so the client id takes the place of the channel and port
+
+// return the counterparty client id registered for IBC v2, empty if not registered or the chain doesn't support IBC v2
+func (c *Client) GetCounterpartyInfo(ctx context.Context, clientId string) (string, error) {
+	resp, err := c.clientV2QueryClient.CounterpartyInfo(
+		ctx,
+		&clientV2Types.QueryCounterpartyInfoRequest{
+			ClientId: clientId,
+		},
+	)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound, codes.Unimplemented:
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to get counterparty info for client: %s", clientId)
+	}
+
+	if resp.CounterpartyInfo == nil {
+		return "", nil
+	}
+	return resp.CounterpartyInfo.ClientId, nil
+}
+
+func (c *Client) GetNextSequenceSendV2(ctx context.Context, clientId string) (uint64, error) {
+	resp, err := c.channelV2QueryClient.NextSequenceSend(
+		ctx,
+		&channelV2Types.QueryNextSequenceSendRequest{
+			ClientId: clientId,
+		},
+	)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get next sequence send for client: %s", clientId)
+	}
+
+	return resp.NextSequenceSend, nil
+}
+
+// return sequences of packets which are sent but not acknowledged or timed out yet
+func (c *Client) GetPacketCommitmentsV2(ctx context.Context, clientId string) ([]uint64, error) {
+	var sequences []uint64
+
+	page := &query.PageRequest{}
+	for {
+		resp, err := c.channelV2QueryClient.PacketCommitments(
+			ctx,
+			&channelV2Types.QueryPacketCommitmentsRequest{
+				ClientId:   clientId,
+				Pagination: page,
+			},
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get packet commitments for client: %s", clientId)
+		}
+
+		for _, commitment := range resp.Commitments {
+			sequences = append(sequences, commitment.Sequence)
+		}
+
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			break
+		}
+		page.Key = resp.Pagination.NextKey
+	}
+
+	return sequences, nil
+}
+
+// return sequences of packets which are not received on this chain among the given commitments of counterparty
+func (c *Client) GetUnreceivedPacketsV2(ctx context.Context, clientId string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+
+	resp, err := c.channelV2QueryClient.UnreceivedPackets(
+		ctx,
+		&channelV2Types.QueryUnreceivedPacketsRequest{
+			ClientId:  clientId,
+			Sequences: sequences,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get unreceived packets for client: %s", clientId)
+	}
+
+	return resp.Sequences, nil
+}
+
+// return sequences of acknowledgements written on this chain among the given commitments of counterparty
+func (c *Client) GetPacketAcknowledgementsV2(ctx context.Context, clientId string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+
+	var ackSequences []uint64
+
+	page := &query.PageRequest{}
+	for {
+		resp, err := c.channelV2QueryClient.PacketAcknowledgements(
+			ctx,
+			&channelV2Types.QueryPacketAcknowledgementsRequest{
+				ClientId:                  clientId,
+				Pagination:                page,
+				PacketCommitmentSequences: sequences,
+			},
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get packet acknowledgements for client: %s", clientId)
+		}
+
+		for _, ack := range resp.Acknowledgements {
+			ackSequences = append(ackSequences, ack.Sequence)
+		}
+
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			break
+		}
+		page.Key = resp.Pagination.NextKey
+	}
+
+	return ackSequences, nil
+}
+
+// return sequences of acknowledgements which are not relayed to this chain among the given acknowledgements of counterparty
+func (c *Client) GetUnreceivedAcksV2(ctx context.Context, clientId string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+
+	resp, err := c.channelV2QueryClient.UnreceivedAcks(
+		ctx,
+		&channelV2Types.QueryUnreceivedAcksRequest{
+			ClientId:           clientId,
+			PacketAckSequences: sequences,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get unreceived acks for client: %s", clientId)
+	}
+
+	return resp.Sequences, nil
+}
@@ -1,27 +1,51 @@
 package grpc
 
 import (
+	"sync"
+
+	"github.com/dlvlabs/ibcmon/client/endpoint"
+	"github.com/dlvlabs/ibcmon/client/retry"
+
 	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
 	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
+	clientV2Types "github.com/cosmos/ibc-go/v10/modules/core/02-client/v2/types"
 	connectionTypes "github.com/cosmos/ibc-go/v10/modules/core/03-connection/types"
 	channelTypes "github.com/cosmos/ibc-go/v10/modules/core/04-channel/types"
+	channelV2Types "github.com/cosmos/ibc-go/v10/modules/core/04-channel/v2/types"
 	"google.golang.org/grpc"
 )
 
 type Client struct {
-	host    string
+	pool    *endpoint.Pool
 	tlsConn bool
-	conn    *grpc.ClientConn
+	retry   retry.Policy
+
+	// connections to every endpoint, requests are sent through the active one
+	connsMutex sync.RWMutex
+	conns      []*grpc.ClientConn
 
 	clientQueryClient     clientTypes.QueryClient
 	connectionQueryClient connectionTypes.QueryClient
 	channelQueryClient    channelTypes.QueryClient
+	clientV2QueryClient   clientV2Types.QueryClient
+	channelV2QueryClient  channelV2Types.QueryClient
 	cmtServiceClient      cmtservice.ServiceClient
 }
 
-func New(host string, tlsConn bool) *Client {
-	return &Client{
-		host:    host,
+func New(hosts []string, tlsConn bool, retry retry.Policy) *Client {
+	c := &Client{
+		pool:    endpoint.NewPool(hosts),
 		tlsConn: tlsConn,
+		retry:   retry,
 	}
+
+	// query clients send requests through c.Invoke
+	c.clientQueryClient = clientTypes.NewQueryClient(c)
+	c.connectionQueryClient = connectionTypes.NewQueryClient(c)
+	c.channelQueryClient = channelTypes.NewQueryClient(c)
+	c.clientV2QueryClient = clientV2Types.NewQueryClient(c)
+	c.channelV2QueryClient = channelV2Types.NewQueryClient(c)
+	c.cmtServiceClient = cmtservice.NewServiceClient(c)
+
+	return c
 }
@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy decides how a failed request is retried, shared by rpc and grpc clients
+type Policy struct {
+	// max number of attempts including the first one, 1 disables retry
+	Attempts uint `toml:"attempts"`
+	// delay before the first retry, doubled on each retry up to MaxBackoff
+	InitialBackoff time.Duration `toml:"initial_backoff"`
+	MaxBackoff     time.Duration `toml:"max_backoff"`
+	// each delay is randomized within ±Jitter ratio, e.g. 0.2, 0 means no jitter
+	Jitter float64 `toml:"jitter"`
+	// no more retry after this duration since the first attempt, 0 means no limit
+	MaxElapsed time.Duration `toml:"max_elapsed"`
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Attempts:       5,
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     30 * time.Second,
+		Jitter:         0.2,
+		MaxElapsed:     2 * time.Minute,
+	}
+}
+
+// WithDefaults fills zero Attempts, InitialBackoff and MaxBackoff with DefaultPolicy,
+// zero Jitter and MaxElapsed are kept since they disable jitter and the elapsed limit
+func (p Policy) WithDefaults() Policy {
+	defaults := DefaultPolicy()
+	if p.Attempts == 0 {
+		p.Attempts = defaults.Attempts
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = defaults.InitialBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = defaults.MaxBackoff
+	}
+	return p
+}
+
+// Do calls request until it succeeds, and returns the last error
+// if the error is not retryable, the policy is exhausted or ctx is done.
+// onRetry is called before each retry with the error of the previous attempt.
+func (p Policy) Do(
+	ctx context.Context,
+	retryable func(error) bool,
+	onRetry func(attempt uint, err error),
+	request func() error,
+) error {
+	start := time.Now()
+	backoff := p.InitialBackoff
+
+	var err error
+	for attempt := uint(1); ; attempt++ {
+		err = request()
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		if attempt >= p.Attempts {
+			return err
+		}
+
+		delay := p.jitter(backoff)
+		if p.MaxElapsed != 0 && time.Since(start)+delay > p.MaxElapsed {
+			return err
+		}
+
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+}
+
+func (p Policy) jitter(backoff time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return backoff
+	}
+
+	// [1 - jitter, 1 + jitter)
+	ratio := 1 + p.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(backoff) * ratio)
+}
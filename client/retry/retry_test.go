@@ -0,0 +1,218 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	errTemporary = errors.New("temporary")
+	errPermanent = errors.New("permanent")
+)
+
+func TestDo(t *testing.T) {
+	policy := Policy{
+		Attempts:       3,
+		InitialBackoff: 1 * time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name string
+
+		policy Policy
+		// errors returned by each attempt, nil after they run out
+		errs []error
+
+		expectedErr      error
+		expectedAttempts int
+	}{
+		{
+			name:             "success at once",
+			policy:           policy,
+			expectedErr:      nil,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "success after retries",
+			policy:           policy,
+			errs:             []error{errTemporary, errTemporary},
+			expectedErr:      nil,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "attempts exhausted",
+			policy:           policy,
+			errs:             []error{errTemporary, errTemporary, errTemporary, errTemporary},
+			expectedErr:      errTemporary,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "permanent error is not retried",
+			policy:           policy,
+			errs:             []error{errTemporary, errPermanent, errTemporary},
+			expectedErr:      errPermanent,
+			expectedAttempts: 2,
+		},
+		{
+			name: "1 attempt disables retry",
+			policy: Policy{
+				Attempts:       1,
+				InitialBackoff: 1 * time.Millisecond,
+				MaxBackoff:     1 * time.Millisecond,
+			},
+			errs:             []error{errTemporary},
+			expectedErr:      errTemporary,
+			expectedAttempts: 1,
+		},
+		{
+			name: "max elapsed exceeded by the next delay",
+			policy: Policy{
+				Attempts:       5,
+				InitialBackoff: 1 * time.Hour,
+				MaxBackoff:     1 * time.Hour,
+				MaxElapsed:     1 * time.Minute,
+			},
+			errs:             []error{errTemporary, errTemporary},
+			expectedErr:      errTemporary,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts, retries := 0, 0
+			err := test.policy.Do(
+				context.Background(),
+				func(err error) bool { return errors.Is(err, errTemporary) },
+				func(uint, error) { retries++ },
+				func() error {
+					attempts++
+					if attempts > len(test.errs) {
+						return nil
+					}
+					return test.errs[attempts-1]
+				},
+			)
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if attempts != test.expectedAttempts {
+				t.Fatalf("expected %d attempts, got %d", test.expectedAttempts, attempts)
+			}
+			if retries != attempts-1 {
+				t.Fatalf("expected %d retries, got %d", attempts-1, retries)
+			}
+		})
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	policy := Policy{
+		Attempts:       5,
+		InitialBackoff: 1 * time.Hour,
+		MaxBackoff:     1 * time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	attempts := 0
+	err := policy.Do(ctx, func(error) bool { return true }, nil, func() error {
+		attempts++
+		return errTemporary
+	})
+
+	if !errors.Is(err, errTemporary) {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("backoff should be stopped by ctx, got %d attempts", attempts)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tests := []struct {
+		name string
+
+		jitter float64
+
+		min time.Duration
+		max time.Duration
+	}{
+		{
+			name:   "no jitter",
+			jitter: 0,
+			min:    1 * time.Second,
+			max:    1 * time.Second,
+		},
+		{
+			name:   "20% jitter",
+			jitter: 0.2,
+			min:    800 * time.Millisecond,
+			max:    1200 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := Policy{Jitter: test.jitter}
+			for range 100 {
+				delay := policy.jitter(1 * time.Second)
+				if delay < test.min || delay > test.max {
+					t.Fatalf("expected delay in [%s, %s], got %s", test.min, test.max, delay)
+				}
+			}
+		})
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	defaults := DefaultPolicy()
+
+	tests := []struct {
+		name string
+
+		policy   Policy
+		expected Policy
+	}{
+		{
+			name:   "zero values are filled",
+			policy: Policy{Jitter: 0.1},
+			expected: Policy{
+				Attempts:       defaults.Attempts,
+				InitialBackoff: defaults.InitialBackoff,
+				MaxBackoff:     defaults.MaxBackoff,
+				Jitter:         0.1,
+			},
+		},
+		{
+			name: "set values are kept",
+			policy: Policy{
+				Attempts:       1,
+				InitialBackoff: 2 * time.Second,
+				MaxBackoff:     3 * time.Second,
+				MaxElapsed:     4 * time.Second,
+			},
+			expected: Policy{
+				Attempts:       1,
+				InitialBackoff: 2 * time.Second,
+				MaxBackoff:     3 * time.Second,
+				MaxElapsed:     4 * time.Second,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.policy.WithDefaults()
+			if result != test.expected {
+				t.Fatalf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
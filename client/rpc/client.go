@@ -1,30 +1,132 @@
 package rpc
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/client/endpoint"
 	"github.com/dlvlabs/ibcmon/logger"
 	"github.com/pkg/errors"
+
+	cmthttp "github.com/cometbft/cometbft/rpc/client/http"
+	rpcTypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
 )
 
+// timeout of a health check request
+const HEALTH_CHECK_TIMEOUT = 5 * time.Second
+
 func (c *Client) Connect() error {
-	// for websocket connection
-	err := c.rpcClient.Start()
-	if err != nil {
-		return errors.Wrap(err, "failed to connect to rpc client")
-	}
+	// for websocket connection, started on the active endpoint or the next one
+	var err error
+	for range c.pool.Len() {
+		i := c.pool.Active()
+		err = c.rpcClients[i].Start()
+		if err == nil {
+			c.connected = i
 
-	logger.Info("RPC connected")
+			msg := fmt.Sprintf("RPC connected: %s", c.pool.Host(i))
+			logger.Info(msg)
 
-	return nil
+			return nil
+		}
+
+		if !c.pool.Failover(i, err) {
+			break
+		}
+	}
+
+	return errors.Wrap(err, "failed to connect to rpc client")
 }
 
 func (c *Client) Terminate() error {
+	if c.connected < 0 {
+		return nil
+	}
+
 	// for websocket connection
-	err := c.rpcClient.Stop()
+	err := c.rpcClients[c.connected].Stop()
 	if err != nil {
 		return errors.Wrap(err, "failed to terminate rpc client")
 	}
+	c.connected = -1
 
 	logger.Info("RPC connection terminated")
 
 	return nil
 }
+
+// Failover switches the active endpoint, e.g. when a subscription on it is interrupted
+func (c *Client) Failover(err error) {
+	c.pool.Failover(c.pool.Active(), err)
+}
+
+// send the request through the active endpoint, and the next endpoint on failure,
+// it's retried with the retry policy if every endpoint fails
+func (c *Client) call(ctx context.Context, name string, request func(*cmthttp.HTTP) error) error {
+	var failed int
+	onRetry := func(attempt uint, err error) {
+		c.pool.Retry(failed)
+
+		msg := fmt.Sprintf("Retrying(attempt %d) %s: %s", attempt, name, err)
+		logger.Debug(msg)
+	}
+
+	return c.retry.Do(ctx, isRetryable, onRetry, func() error {
+		var err error
+		for range c.pool.Len() {
+			i := c.pool.Active()
+			err = request(c.rpcClients[i])
+			c.pool.Observe(i, err)
+
+			if err == nil || ctx.Err() != nil || !isRetryable(err) {
+				return err
+			}
+			failed = i
+
+			if !c.pool.Failover(i, err) {
+				return err
+			}
+		}
+
+		return err
+	})
+}
+
+// errors returned by the node for the request, e.g. invalid query or disabled tx indexing, are permanent,
+// and the others such as connection errors and timeouts are temporary
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var rpcErr *rpcTypes.RPCError
+	return !errors.As(err, &rpcErr)
+}
+
+// CheckEndpoints measures latency of every endpoint and activates the fastest one
+func (c *Client) CheckEndpoints(ctx context.Context) {
+	for i, rpcClient := range c.rpcClients {
+		latency, err := checkEndpoint(ctx, rpcClient)
+		c.pool.SetHealth(i, latency, err)
+	}
+
+	c.pool.Select()
+}
+
+func checkEndpoint(ctx context.Context, rpcClient *cmthttp.HTTP) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, HEALTH_CHECK_TIMEOUT)
+	defer cancel()
+
+	start := time.Now()
+	_, err := rpcClient.ABCIInfo(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get ABCI info")
+	}
+
+	return time.Since(start), nil
+}
+
+func (c *Client) EndpointStatuses() []endpoint.Status {
+	return c.pool.Statuses()
+}
@@ -0,0 +1,420 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/pkg/errors"
+
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+	cmtTypes "github.com/cometbft/cometbft/types"
+	txTypes "github.com/cosmos/cosmos-sdk/types/tx"
+	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
+	channelTypesV2 "github.com/cosmos/ibc-go/v10/modules/core/04-channel/v2/types"
+)
+
+// capacity of subscription channels, cometbft drops events when the channel is full
+const SUBSCRIPTION_CAPACITY = 1024
+
+type IBCPacketEvent struct {
+	PacketType string
+	Code       uint32
+	Hash       string
+	Height     int64
+	// set by SetBlockTimes, zero if it's unknown
+	BlockTime time.Time
+	// relayer who signed the tx and the memo of the tx, relayers often tag their software and version in the memo
+	Signer string
+	Memo   string
+
+	Sequence     uint64
+	SrcChannelId string
+	SrcPortId    string
+	DstChannelId string
+	DstPortId    string
+
+	Data string
+	// zero if the packet times out only with the timestamp
+	TimeoutHeight    clientTypes.Height
+	TimeoutTimestamp int64
+
+	// set on recv_packet if the app wrote an error acknowledgement in the same tx, e.g. ICS-20 {"error": ...}
+	ErrorAck bool
+	AckError string
+}
+
+// packet_data is removed in ibc-go v10, only packet_data_hex is emitted since then
+const (
+	ATTRIBUTE_DATA     = "packet_data"
+	ATTRIBUTE_DATA_HEX = "packet_data_hex"
+)
+
+// attributes of IBC v2 packet events, packets are routed by client ids without ports
+const (
+	ATTRIBUTE_V2_SRC_CLIENT     = "packet_source_client"
+	ATTRIBUTE_V2_DST_CLIENT     = "packet_dest_client"
+	ATTRIBUTE_V2_ENCODED_PACKET = "encoded_packet_hex"
+)
+
+// acknowledgements are written by recv_packet in the same tx, unless the app acknowledges asynchronously
+const (
+	EVENT_RECV_PACKET = "recv_packet"
+	EVENT_WRITE_ACK   = "write_acknowledgement"
+
+	// removed in ibc-go v10, packet_ack_hex and encoded_acknowledgement_hex of IBC v2 are emitted since then
+	ATTRIBUTE_ACK            = "packet_ack"
+	ATTRIBUTE_ACK_HEX        = "packet_ack_hex"
+	ATTRIBUTE_V2_ENCODED_ACK = "encoded_acknowledgement_hex"
+
+	// IBC v2 error acknowledgement doesn't have the error
+	V2_ACK_ERROR = "universal error acknowledgement"
+)
+
+// events of the tx which the relayer is found from
+const (
+	EVENT_SENDER    = "message.sender"
+	EVENT_FEE_PAYER = "tx.fee_payer"
+)
+
+// Clone creates a new client for the same endpoints, used to own a websocket connection,
+// health and stats of the endpoints are shared with the original
+func (c *Client) Clone() (*Client, error) {
+	return newClient(c.pool, c.retry)
+}
+
+// SubscribeIBCPackets subscribes every `packetType` event of txs,
+// one IBCPacketEvent is emitted per packet even if a tx has many packets.
+func (c *Client) SubscribeIBCPackets(ctx context.Context, packetType string) (<-chan IBCPacketEvent, error) {
+	query := fmt.Sprintf("tm.event='Tx' AND %s.packet_sequence EXISTS", packetType)
+
+	resultEvents, err := c.Subscribe(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	ibcPacketEvents := make(chan IBCPacketEvent, SUBSCRIPTION_CAPACITY)
+	go func() {
+		defer close(ibcPacketEvents)
+
+		for {
+			select {
+			case resultEvent := <-resultEvents:
+				events, err := parseIBCPacketEvents(packetType, resultEvent.Events)
+				if err != nil {
+					logger.Error(err)
+					continue
+				}
+
+				memo := ""
+				if tx, ok := resultEvent.Data.(cmtTypes.EventDataTx); ok {
+					memo = txMemo(tx.Tx)
+				}
+				for i := range events {
+					events[i].Memo = memo
+				}
+
+				for _, event := range events {
+					select {
+					case ibcPacketEvents <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ibcPacketEvents, nil
+}
+
+// SubscribeNewBlockHeight emits the height of every new block
+func (c *Client) SubscribeNewBlockHeight(ctx context.Context) (<-chan int64, error) {
+	query := fmt.Sprintf("tm.event='%s'", cmtTypes.EventNewBlockHeader)
+
+	resultEvents, err := c.Subscribe(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	heights := make(chan int64, SUBSCRIPTION_CAPACITY)
+	go func() {
+		defer close(heights)
+
+		for {
+			select {
+			case resultEvent := <-resultEvents:
+				header, ok := resultEvent.Data.(cmtTypes.EventDataNewBlockHeader)
+				if !ok {
+					continue
+				}
+
+				select {
+				case heights <- header.Header.Height:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return heights, nil
+}
+
+func parseIBCPacketEvent(packetType string, attributes []abciTypes.EventAttribute) (IBCPacketEvent, error) {
+	event := IBCPacketEvent{
+		PacketType: packetType,
+	}
+
+	v2 := false
+	var data, dataHex, ack, ackHex, v2AckHex string
+	for _, attr := range attributes {
+		key, value := decodeAttribute(attr)
+
+		var err error
+		switch key {
+		case "packet_sequence":
+			event.Sequence, err = strconv.ParseUint(value, 10, 64)
+		case "packet_src_channel":
+			event.SrcChannelId = value
+		case "packet_src_port":
+			event.SrcPortId = value
+		case "packet_dst_channel":
+			event.DstChannelId = value
+		case "packet_dst_port":
+			event.DstPortId = value
+		case ATTRIBUTE_DATA:
+			data = value
+		case ATTRIBUTE_DATA_HEX:
+			dataHex = value
+		case "packet_timeout_height":
+			event.TimeoutHeight, err = clientTypes.ParseHeight(value)
+		case "packet_timeout_timestamp":
+			event.TimeoutTimestamp, err = strconv.ParseInt(value, 10, 64)
+		case ATTRIBUTE_V2_SRC_CLIENT:
+			event.SrcChannelId = value
+			v2 = true
+		case ATTRIBUTE_V2_DST_CLIENT:
+			event.DstChannelId = value
+		case ATTRIBUTE_V2_ENCODED_PACKET:
+			data = value
+		case ATTRIBUTE_ACK:
+			ack = value
+		case ATTRIBUTE_ACK_HEX:
+			ackHex = value
+		case ATTRIBUTE_V2_ENCODED_ACK:
+			v2AckHex = value
+		}
+		if err != nil {
+			return IBCPacketEvent{}, errors.Wrapf(err, "failed to parse %s: %s", key, value)
+		}
+	}
+	event.Data = packetData(data, dataHex)
+	event.ErrorAck, event.AckError = parseAck(ack, ackHex, v2AckHex)
+
+	// timeout timestamp of IBC v2 is in seconds
+	if v2 {
+		event.TimeoutTimestamp *= int64(time.Second)
+	}
+
+	return event, nil
+}
+
+// attributes of the same event type are flattened in order, so n-th values belong to n-th packet,
+// it's assumed that IBC v1 and v2 packets are not mixed in a tx
+func parseIBCPacketEvents(packetType string, events map[string][]string) ([]IBCPacketEvent, error) {
+	attr := func(key string, i int) string {
+		values := events[fmt.Sprintf("%s.%s", packetType, key)]
+		if i >= len(values) {
+			return ""
+		}
+		return values[i]
+	}
+
+	hash := ""
+	if hashes := events["tx.hash"]; len(hashes) > 0 {
+		hash = hashes[0]
+	}
+	var height int64 = 0
+	if heights := events["tx.height"]; len(heights) > 0 {
+		height, _ = strconv.ParseInt(heights[0], 10, 64)
+	}
+	signer := ""
+	if senders := events[EVENT_SENDER]; len(senders) > 0 {
+		signer = senders[0]
+	} else if feePayers := events[EVENT_FEE_PAYER]; len(feePayers) > 0 {
+		signer = feePayers[0]
+	}
+
+	sequences := events[fmt.Sprintf("%s.packet_sequence", packetType)]
+	result := make([]IBCPacketEvent, 0, len(sequences))
+	for i, seq := range sequences {
+		sequence, err := strconv.ParseUint(seq, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse packet sequence: %s(%s)", packetType, hash)
+		}
+
+		event := IBCPacketEvent{
+			PacketType: packetType,
+			Hash:       hash,
+			Height:     height,
+			Signer:     signer,
+
+			Sequence:     sequence,
+			SrcChannelId: attr("packet_src_channel", i),
+			SrcPortId:    attr("packet_src_port", i),
+			DstChannelId: attr("packet_dst_channel", i),
+			DstPortId:    attr("packet_dst_port", i),
+
+			Data: packetData(attr(ATTRIBUTE_DATA, i), attr(ATTRIBUTE_DATA_HEX, i)),
+		}
+
+		v2 := attr(ATTRIBUTE_V2_SRC_CLIENT, i) != ""
+		if v2 {
+			event.SrcChannelId = attr(ATTRIBUTE_V2_SRC_CLIENT, i)
+			event.DstChannelId = attr(ATTRIBUTE_V2_DST_CLIENT, i)
+			event.Data = attr(ATTRIBUTE_V2_ENCODED_PACKET, i)
+		}
+		event.ErrorAck, event.AckError = parseAck(attr(ATTRIBUTE_ACK, i), attr(ATTRIBUTE_ACK_HEX, i), attr(ATTRIBUTE_V2_ENCODED_ACK, i))
+
+		if timeoutHeight := attr("packet_timeout_height", i); timeoutHeight != "" {
+			event.TimeoutHeight, err = clientTypes.ParseHeight(timeoutHeight)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse timeout height: %s(%s)", packetType, hash)
+			}
+		}
+		if timeoutTimestamp := attr("packet_timeout_timestamp", i); timeoutTimestamp != "" {
+			event.TimeoutTimestamp, err = strconv.ParseInt(timeoutTimestamp, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse timeout timestamp: %s(%s)", packetType, hash)
+			}
+			// timeout timestamp of IBC v2 is in seconds
+			if v2 {
+				event.TimeoutTimestamp *= int64(time.Second)
+			}
+		}
+
+		result = append(result, event)
+	}
+
+	if packetType == EVENT_RECV_PACKET {
+		writeAcks, err := parseIBCPacketEvents(EVENT_WRITE_ACK, events)
+		if err != nil {
+			return nil, err
+		}
+		result = withWriteAcks(result, writeAcks)
+	}
+
+	return result, nil
+}
+
+// signer of the tx, the sender of the first message or the fee payer if messages don't have the sender
+func txSigner(events []abciTypes.Event) string {
+	feePayer := ""
+	for _, event := range events {
+		for _, attr := range event.Attributes {
+			key, value := decodeAttribute(attr)
+			switch fmt.Sprintf("%s.%s", tryBase64Decoding(event.Type), key) {
+			case EVENT_SENDER:
+				return value
+			case EVENT_FEE_PAYER:
+				if feePayer == "" {
+					feePayer = value
+				}
+			}
+		}
+	}
+	return feePayer
+}
+
+// memo of the tx, empty if the tx fails to be decoded
+func txMemo(tx []byte) string {
+	var txRaw txTypes.TxRaw
+	err := txRaw.Unmarshal(tx)
+	if err != nil {
+		return ""
+	}
+
+	var body txTypes.TxBody
+	err = body.Unmarshal(txRaw.BodyBytes)
+	if err != nil {
+		return ""
+	}
+	return body.Memo
+}
+
+// packet data of IBC v1, decoded from packet_data_hex if packet_data is not emitted
+func packetData(data, dataHex string) string {
+	if data != "" || dataHex == "" {
+		return data
+	}
+
+	bz, err := hex.DecodeString(dataHex)
+	if err != nil {
+		return dataHex
+	}
+	return string(bz)
+}
+
+// classify the acknowledgement, return whether it's an error acknowledgement and its error.
+// acks which are not {"result": ...} or {"error": ...} of ICS-20 and ICS-27 are regarded as successful
+func parseAck(ack, ackHex, v2AckHex string) (bool, string) {
+	if v2AckHex != "" {
+		bz, err := hex.DecodeString(v2AckHex)
+		if err != nil {
+			return false, ""
+		}
+
+		var acknowledgement channelTypesV2.Acknowledgement
+		err = acknowledgement.Unmarshal(bz)
+		if err != nil || len(acknowledgement.AppAcknowledgements) == 0 || acknowledgement.Success() {
+			return false, ""
+		}
+		return true, V2_ACK_ERROR
+	}
+
+	if ackHex != "" {
+		bz, err := hex.DecodeString(ackHex)
+		if err == nil {
+			ack = string(bz)
+		}
+	}
+	if ack == "" {
+		return false, ""
+	}
+
+	var acknowledgement struct {
+		Error *string `json:"error"`
+	}
+	err := json.Unmarshal([]byte(ack), &acknowledgement)
+	if err != nil || acknowledgement.Error == nil {
+		return false, ""
+	}
+	return true, *acknowledgement.Error
+}
+
+// copy the acknowledgements written in the same tx to recv_packet events of the same packets
+func withWriteAcks(recvs, writeAcks []IBCPacketEvent) []IBCPacketEvent {
+	for i, recv := range recvs {
+		for _, writeAck := range writeAcks {
+			if writeAck.Sequence != recv.Sequence ||
+				writeAck.SrcChannelId != recv.SrcChannelId || writeAck.SrcPortId != recv.SrcPortId ||
+				writeAck.DstChannelId != recv.DstChannelId || writeAck.DstPortId != recv.DstPortId {
+				continue
+			}
+
+			recvs[i].ErrorAck = writeAck.ErrorAck
+			recvs[i].AckError = writeAck.AckError
+			break
+		}
+	}
+	return recvs
+}
@@ -0,0 +1,377 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"testing"
+
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+	txTypes "github.com/cosmos/cosmos-sdk/types/tx"
+	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
+	channelTypesV2 "github.com/cosmos/ibc-go/v10/modules/core/04-channel/v2/types"
+)
+
+// hex of the IBC v2 acknowledgement with the app acknowledgement
+func v2AckHex(t *testing.T, appAck []byte) string {
+	t.Helper()
+
+	ack := channelTypesV2.Acknowledgement{AppAcknowledgements: [][]byte{appAck}}
+	bz, err := ack.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal acknowledgement: %v", err)
+	}
+	return hex.EncodeToString(bz)
+}
+
+func TestParseAck(t *testing.T) {
+	tests := []struct {
+		name string
+
+		ack      string
+		ackHex   string
+		v2AckHex string
+
+		expectedErrorAck bool
+		expectedAckError string
+	}{
+		{
+			name:             "no ack",
+			expectedErrorAck: false,
+		},
+		{
+			name:             "result",
+			ack:              `{"result":"AQ=="}`,
+			expectedErrorAck: false,
+		},
+		{
+			name:             "error",
+			ack:              `{"error":"ABCI code: 6: error handling packet: see events for details"}`,
+			expectedErrorAck: true,
+			expectedAckError: "ABCI code: 6: error handling packet: see events for details",
+		},
+		{
+			name:             "error in hex",
+			ackHex:           hex.EncodeToString([]byte(`{"error":"insufficient funds"}`)),
+			expectedErrorAck: true,
+			expectedAckError: "insufficient funds",
+		},
+		{
+			name:             "invalid hex is ignored",
+			ack:              `{"result":"AQ=="}`,
+			ackHex:           "zz",
+			expectedErrorAck: false,
+		},
+		{
+			name:             "ack of other apps",
+			ack:              "\x01",
+			expectedErrorAck: false,
+		},
+		{
+			name:             "v2 success",
+			v2AckHex:         v2AckHex(t, []byte(`{"result":"AQ=="}`)),
+			expectedErrorAck: false,
+		},
+		{
+			name:             "v2 error",
+			v2AckHex:         v2AckHex(t, channelTypesV2.ErrorAcknowledgement[:]),
+			expectedErrorAck: true,
+			expectedAckError: V2_ACK_ERROR,
+		},
+		{
+			name:             "v2 invalid hex",
+			v2AckHex:         "zz",
+			expectedErrorAck: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errorAck, ackError := parseAck(test.ack, test.ackHex, test.v2AckHex)
+			if errorAck != test.expectedErrorAck {
+				t.Fatalf("expected error ack %t, got %t", test.expectedErrorAck, errorAck)
+			}
+			if ackError != test.expectedAckError {
+				t.Fatalf("expected %q, got %q", test.expectedAckError, ackError)
+			}
+		})
+	}
+}
+
+func TestPacketData(t *testing.T) {
+	tests := []struct {
+		name string
+
+		data    string
+		dataHex string
+
+		expected string
+	}{
+		{name: "data", data: `{"amount":"1"}`, expected: `{"amount":"1"}`},
+		{name: "data is preferred", data: `{"amount":"1"}`, dataHex: hex.EncodeToString([]byte(`{"amount":"2"}`)), expected: `{"amount":"1"}`},
+		{name: "hex", dataHex: hex.EncodeToString([]byte(`{"amount":"2"}`)), expected: `{"amount":"2"}`},
+		{name: "invalid hex is kept", dataHex: "zz", expected: "zz"},
+		{name: "empty", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := packetData(test.data, test.dataHex)
+			if result != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestWithWriteAcks(t *testing.T) {
+	recv := func(sequence uint64, channelId string) IBCPacketEvent {
+		return IBCPacketEvent{
+			PacketType:   EVENT_RECV_PACKET,
+			Sequence:     sequence,
+			SrcChannelId: channelId,
+			SrcPortId:    "transfer",
+			DstChannelId: "channel-1",
+			DstPortId:    "transfer",
+		}
+	}
+	writeAck := func(sequence uint64, channelId string, ackError string) IBCPacketEvent {
+		event := recv(sequence, channelId)
+		event.PacketType = EVENT_WRITE_ACK
+		event.ErrorAck = ackError != ""
+		event.AckError = ackError
+		return event
+	}
+
+	tests := []struct {
+		name string
+
+		recvs     []IBCPacketEvent
+		writeAcks []IBCPacketEvent
+
+		expected []string
+	}{
+		{
+			name:      "error ack of the same packet",
+			recvs:     []IBCPacketEvent{recv(1, "channel-0"), recv(2, "channel-0")},
+			writeAcks: []IBCPacketEvent{writeAck(1, "channel-0", ""), writeAck(2, "channel-0", "insufficient funds")},
+			expected:  []string{"", "insufficient funds"},
+		},
+		{
+			name:      "ack of other channel is ignored",
+			recvs:     []IBCPacketEvent{recv(1, "channel-0")},
+			writeAcks: []IBCPacketEvent{writeAck(1, "channel-2", "insufficient funds")},
+			expected:  []string{""},
+		},
+		{
+			name:     "acknowledged asynchronously",
+			recvs:    []IBCPacketEvent{recv(1, "channel-0")},
+			expected: []string{""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := withWriteAcks(test.recvs, test.writeAcks)
+			for i, event := range result {
+				if event.ErrorAck != (test.expected[i] != "") || event.AckError != test.expected[i] {
+					t.Fatalf("expected %q, got %t %q", test.expected[i], event.ErrorAck, event.AckError)
+				}
+			}
+		})
+	}
+}
+
+func TestParseIBCPacketEvents(t *testing.T) {
+	tests := []struct {
+		name string
+
+		packetType string
+		events     map[string][]string
+
+		expected []IBCPacketEvent
+		err      bool
+	}{
+		{
+			name:       "packets of v1",
+			packetType: "send_packet",
+			events: map[string][]string{
+				"tx.hash":                              {"HASH"},
+				"tx.height":                            {"100"},
+				EVENT_SENDER:                           {"cosmos1relayer"},
+				"send_packet.packet_sequence":          {"1", "2"},
+				"send_packet.packet_src_channel":       {"channel-0", "channel-0"},
+				"send_packet.packet_src_port":          {"transfer", "transfer"},
+				"send_packet.packet_dst_channel":       {"channel-1", "channel-1"},
+				"send_packet.packet_dst_port":          {"transfer", "transfer"},
+				"send_packet.packet_data_hex":          {hex.EncodeToString([]byte("a")), hex.EncodeToString([]byte("b"))},
+				"send_packet.packet_timeout_height":    {"1-200", "0-0"},
+				"send_packet.packet_timeout_timestamp": {"0", "1700000000000000000"},
+			},
+			expected: []IBCPacketEvent{
+				{
+					PacketType: "send_packet", Hash: "HASH", Height: 100, Signer: "cosmos1relayer",
+					Sequence: 1, SrcChannelId: "channel-0", SrcPortId: "transfer", DstChannelId: "channel-1", DstPortId: "transfer",
+					Data: "a", TimeoutHeight: clientTypes.NewHeight(1, 200),
+				},
+				{
+					PacketType: "send_packet", Hash: "HASH", Height: 100, Signer: "cosmos1relayer",
+					Sequence: 2, SrcChannelId: "channel-0", SrcPortId: "transfer", DstChannelId: "channel-1", DstPortId: "transfer",
+					Data: "b", TimeoutTimestamp: 1700000000000000000,
+				},
+			},
+		},
+		{
+			name:       "packet of v2 with timeout in seconds",
+			packetType: "send_packet",
+			events: map[string][]string{
+				"tx.hash":                              {"HASH"},
+				EVENT_FEE_PAYER:                        {"cosmos1payer"},
+				"send_packet.packet_sequence":          {"3"},
+				"send_packet.packet_source_client":     {"07-tendermint-0"},
+				"send_packet.packet_dest_client":       {"08-wasm-1"},
+				"send_packet.encoded_packet_hex":       {"0a01"},
+				"send_packet.packet_timeout_timestamp": {"1700000000"},
+			},
+			expected: []IBCPacketEvent{
+				{
+					PacketType: "send_packet", Hash: "HASH", Signer: "cosmos1payer",
+					Sequence: 3, SrcChannelId: "07-tendermint-0", DstChannelId: "08-wasm-1",
+					Data: "0a01", TimeoutTimestamp: 1700000000000000000,
+				},
+			},
+		},
+		{
+			name:       "recv packet with error ack written in the same tx",
+			packetType: EVENT_RECV_PACKET,
+			events: map[string][]string{
+				"recv_packet.packet_sequence":              {"1"},
+				"recv_packet.packet_src_channel":           {"channel-0"},
+				"recv_packet.packet_src_port":              {"transfer"},
+				"recv_packet.packet_dst_channel":           {"channel-1"},
+				"recv_packet.packet_dst_port":              {"transfer"},
+				"write_acknowledgement.packet_sequence":    {"1"},
+				"write_acknowledgement.packet_src_channel": {"channel-0"},
+				"write_acknowledgement.packet_src_port":    {"transfer"},
+				"write_acknowledgement.packet_dst_channel": {"channel-1"},
+				"write_acknowledgement.packet_dst_port":    {"transfer"},
+				"write_acknowledgement.packet_ack_hex":     {hex.EncodeToString([]byte(`{"error":"insufficient funds"}`))},
+			},
+			expected: []IBCPacketEvent{
+				{
+					PacketType: EVENT_RECV_PACKET,
+					Sequence:   1, SrcChannelId: "channel-0", SrcPortId: "transfer", DstChannelId: "channel-1", DstPortId: "transfer",
+					ErrorAck: true, AckError: "insufficient funds",
+				},
+			},
+		},
+		{
+			name:       "invalid sequence",
+			packetType: "send_packet",
+			events: map[string][]string{
+				"send_packet.packet_sequence": {"one"},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := parseIBCPacketEvents(test.packetType, test.events)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %d events, got %d: %+v", len(test.expected), len(result), result)
+			}
+			for i, event := range result {
+				if event != test.expected[i] {
+					t.Fatalf("expected %+v, got %+v", test.expected[i], event)
+				}
+			}
+		})
+	}
+}
+
+func TestTxSigner(t *testing.T) {
+	attr := func(key, value string) abciTypes.EventAttribute {
+		return abciTypes.EventAttribute{Key: key, Value: value}
+	}
+
+	tests := []struct {
+		name string
+
+		events []abciTypes.Event
+
+		expected string
+	}{
+		{
+			name: "sender of the first message",
+			events: []abciTypes.Event{
+				{Type: "tx", Attributes: []abciTypes.EventAttribute{attr("fee_payer", "cosmos1payer")}},
+				{Type: "message", Attributes: []abciTypes.EventAttribute{attr("action", "/ibc.core.client.v1.MsgUpdateClient"), attr("sender", "cosmos1relayer")}},
+				{Type: "message", Attributes: []abciTypes.EventAttribute{attr("sender", "cosmos1other")}},
+			},
+			expected: "cosmos1relayer",
+		},
+		{
+			name: "fee payer without sender",
+			events: []abciTypes.Event{
+				{Type: "tx", Attributes: []abciTypes.EventAttribute{attr("fee_payer", "cosmos1payer")}},
+			},
+			expected: "cosmos1payer",
+		},
+		{
+			name: "base64 encoded",
+			events: []abciTypes.Event{
+				{Type: "bWVzc2FnZQ==", Attributes: []abciTypes.EventAttribute{attr("c2VuZGVy", "Y29zbW9zMXJlbGF5ZXI=")}},
+			},
+			expected: "cosmos1relayer",
+		},
+		{
+			name:     "no events",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := txSigner(test.events)
+			if result != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestTxMemo(t *testing.T) {
+	body := txTypes.TxBody{Memo: "hermes 1.13.1"}
+	bodyBytes, err := body.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal tx body: %v", err)
+	}
+	txRaw := txTypes.TxRaw{BodyBytes: bodyBytes}
+	tx, err := txRaw.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal tx: %v", err)
+	}
+
+	tests := []struct {
+		name string
+
+		tx []byte
+
+		expected string
+	}{
+		{name: "memo", tx: tx, expected: "hermes 1.13.1"},
+		{name: "invalid tx", tx: []byte("invalid"), expected: ""},
+		{name: "empty tx", tx: nil, expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := txMemo(test.tx)
+			if result != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
@@ -1,9 +1,8 @@
 package exported
 
 type IBCPacketTracker interface {
-	GetPacketStatus() string
-	GetSequence() uint64
-
 	GetSrcInfo() (string, string, string)
 	GetDstInfo() (string, string, string)
+	// IBC v2 packets are routed by client ids, which are returned as channel ids without port ids
+	IsV2() bool
 }
@@ -3,90 +3,258 @@ package rpc
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/dlvlabs/ibcmon/client/rpc/exported"
 	"github.com/dlvlabs/ibcmon/logger"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 
+	cmthttp "github.com/cometbft/cometbft/rpc/client/http"
 	coreTypes "github.com/cometbft/cometbft/rpc/core/types"
 	clientTypes "github.com/cosmos/ibc-go/v10/modules/core/02-client/types"
 )
 
-// return code, hash, data, timeoutHeight, timeoutTimestamp, isFound, err
-func (c *Client) SearchIBCPacket(ctx context.Context, ibcPacketTracker exported.IBCPacketTracker, retryingCnt uint8) (uint32, string, string, uint64, int64, bool, error) {
-	packet := ibcPacketTracker.GetPacketStatus()
-	sequence := ibcPacketTracker.GetSequence()
+// max number of txs fetched by a SearchIBCPackets call, the rest would be fetched on next call
+const MAX_SEARCH_TXS = 1000
+
+// SearchIBCPackets returns every `packetType` event of the channel whose sequence is in [fromSequence, toSequence],
+// toSequence 0 means no upper bound
+func (c *Client) SearchIBCPackets(
+	ctx context.Context,
+	ibcPacketTracker exported.IBCPacketTracker,
+	packetType string,
+	fromSequence, toSequence uint64,
+) ([]IBCPacketEvent, error) {
 	_, srcChannelId, srcPortId := ibcPacketTracker.GetSrcInfo()
 	_, dstChannelId, dstPortId := ibcPacketTracker.GetDstInfo()
 
-	query := fmt.Sprintf(
-		"%s.packet_sequence='%d' AND %s.packet_src_channel='%s' AND %s.packet_src_port='%s' AND %s.packet_dst_channel='%s' AND %s.packet_dst_port='%s'",
-		packet, sequence,
-		packet, srcChannelId, packet, srcPortId,
-		packet, dstChannelId, packet, dstPortId,
-	)
+	query := fmt.Sprintf("%s.packet_sequence>=%d AND %s", packetType, fromSequence, packetRouteQuery(ibcPacketTracker, packetType))
+	if toSequence != 0 {
+		query = fmt.Sprintf("%s AND %s.packet_sequence<=%d", query, packetType, toSequence)
+	}
+
+	var result []IBCPacketEvent
+
+	page, perPage := 1, 100
+	for searched := 0; searched < MAX_SEARCH_TXS; page++ {
+		// temporary errors are retried with the retry policy of the client
+		var resp *coreTypes.ResultTxSearch
+		err := c.call(ctx, "SearchIBCPackets", func(rpcClient *cmthttp.HTTP) error {
+			var err error
+			resp, err = rpcClient.TxSearch(ctx, query, false, &page, &perPage, "asc")
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to search tx: %s", query)
+		}
+
+		for _, tx := range resp.Txs {
+			signer, memo := txSigner(tx.TxResult.Events), txMemo(tx.Tx)
+
+			var txEvents, writeAcks []IBCPacketEvent
+			for _, event := range tx.TxResult.Events {
+				eventType := tryBase64Decoding(event.Type)
+				if packetType == EVENT_RECV_PACKET && eventType == EVENT_WRITE_ACK {
+					writeAck, err := parseIBCPacketEvent(eventType, event.Attributes)
+					if err != nil {
+						return nil, errors.Wrapf(err, "failed to parse %s of tx: %s", eventType, tx.Hash)
+					}
+					writeAcks = append(writeAcks, writeAck)
+					continue
+				}
+				if eventType != packetType {
+					// Only “send_packet" or “recv_packet" or “acknowledge_packet” event on each packet is target
+					continue
+				}
+
+				ibcPacketEvent, err := parseIBCPacketEvent(packetType, event.Attributes)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to parse %s of tx: %s", packetType, tx.Hash)
+				}
+
+				if ibcPacketEvent.SrcChannelId != srcChannelId || ibcPacketEvent.SrcPortId != srcPortId ||
+					ibcPacketEvent.DstChannelId != dstChannelId || ibcPacketEvent.DstPortId != dstPortId ||
+					ibcPacketEvent.Sequence < fromSequence || (toSequence != 0 && ibcPacketEvent.Sequence > toSequence) {
+					continue
+				}
+
+				ibcPacketEvent.Code = tx.TxResult.Code
+				ibcPacketEvent.Hash = tx.Hash.String()
+				ibcPacketEvent.Height = tx.Height
+				ibcPacketEvent.Signer = signer
+				ibcPacketEvent.Memo = memo
 
-	resp, err := c.rpcClient.TxSearch(ctx, query, false, nil, nil, "asc")
+				txEvents = append(txEvents, ibcPacketEvent)
+			}
+
+			result = append(result, withWriteAcks(txEvents, writeAcks)...)
+		}
+
+		searched += len(resp.Txs)
+		if len(resp.Txs) < perPage || searched >= resp.TotalCount {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// GetLatestIBCPacketSequence returns the highest sequence of `packetType` events in the latest tx of the channel,
+// return false if there's no such tx
+func (c *Client) GetLatestIBCPacketSequence(
+	ctx context.Context,
+	ibcPacketTracker exported.IBCPacketTracker,
+	packetType string,
+) (uint64, bool, error) {
+	_, srcChannelId, srcPortId := ibcPacketTracker.GetSrcInfo()
+	_, dstChannelId, dstPortId := ibcPacketTracker.GetDstInfo()
+
+	query := packetRouteQuery(ibcPacketTracker, packetType)
+
+	page, perPage := 1, 1
+	var resp *coreTypes.ResultTxSearch
+	err := c.call(ctx, "GetLatestIBCPacketSequence", func(rpcClient *cmthttp.HTTP) error {
+		var err error
+		resp, err = rpcClient.TxSearch(ctx, query, false, &page, &perPage, "desc")
+		return err
+	})
 	if err != nil {
-		// Faced with a temporary error, retry up to 5 times with 10 minutes interval
-		if retryingCnt < 5 {
-			time.Sleep(10 * time.Minute)
-			retryingCnt++
+		return 0, false, errors.Wrapf(err, "failed to search tx: %s", query)
+	}
 
-			msg := fmt.Sprintf("Retrying(attempt %d) SearchIBCPacket: %s", retryingCnt, query)
-			logger.Debug(msg)
+	var sequence uint64 = 0
+	found := false
+	for _, tx := range resp.Txs {
+		for _, event := range tx.TxResult.Events {
+			if tryBase64Decoding(event.Type) != packetType {
+				continue
+			}
 
-			return c.SearchIBCPacket(ctx, ibcPacketTracker, retryingCnt)
+			ibcPacketEvent, err := parseIBCPacketEvent(packetType, event.Attributes)
+			if err != nil {
+				return 0, false, errors.Wrapf(err, "failed to parse %s of tx: %s", packetType, tx.Hash)
+			}
+
+			if ibcPacketEvent.SrcChannelId != srcChannelId || ibcPacketEvent.SrcPortId != srcPortId ||
+				ibcPacketEvent.DstChannelId != dstChannelId || ibcPacketEvent.DstPortId != dstPortId {
+				continue
+			}
+
+			if !found || ibcPacketEvent.Sequence > sequence {
+				sequence = ibcPacketEvent.Sequence
+			}
+			found = true
 		}
+	}
+
+	return sequence, found, nil
+}
+
+// query of `packetType` events sent from the source to the destination of the tracker
+func packetRouteQuery(ibcPacketTracker exported.IBCPacketTracker, packetType string) string {
+	_, srcChannelId, srcPortId := ibcPacketTracker.GetSrcInfo()
+	_, dstChannelId, dstPortId := ibcPacketTracker.GetDstInfo()
+
+	if ibcPacketTracker.IsV2() {
+		return fmt.Sprintf(
+			"%s.packet_source_client='%s' AND %s.packet_dest_client='%s'",
+			packetType, srcChannelId, packetType, dstChannelId,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s.packet_src_channel='%s' AND %s.packet_src_port='%s' AND %s.packet_dst_channel='%s' AND %s.packet_dst_port='%s'",
+		packetType, srcChannelId, packetType, srcPortId,
+		packetType, dstChannelId, packetType, dstPortId,
+	)
+}
 
-		return 1, "", "", 0, 0, false, errors.Wrapf(err, "failed to search tx: %s", query)
-	} else if len(resp.Txs) == 0 {
-		return 0, "", "", 0, 0, false, nil
+// GetBlockTime returns the time of the block at the height
+func (c *Client) GetBlockTime(ctx context.Context, height int64) (time.Time, error) {
+	var resp *coreTypes.ResultHeader
+	err := c.call(ctx, "GetBlockTime", func(rpcClient *cmthttp.HTTP) error {
+		var err error
+		resp, err = rpcClient.Header(ctx, &height)
+		return err
+	})
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to get header: %d", height)
 	}
 
-	var data string = ""
-	var timeoutHeight uint64 = 0
-	var timeoutTimestamp int64 = 0
-	for _, event := range resp.Txs[0].TxResult.Events {
-		if tryBase64Decoding(event.Type) != packet {
-			// Only “send_packet" or “recv_packet" or “acknowledge_packet” event on each packet is target
-			continue
+	return resp.Header.Time, nil
+}
+
+// max number of headers fetched at once by SetBlockTimes
+const MAX_BLOCK_TIME_REQUESTS = 8
+
+// SetBlockTimes sets the block time of each successful event, the block of the same height is fetched once.
+// It's best effort, the block time is left zero if it fails to be fetched
+func (c *Client) SetBlockTimes(ctx context.Context, events []IBCPacketEvent) {
+	heights := make(map[int64]struct{})
+	for _, event := range events {
+		// failed txs are not measured
+		if event.Height != 0 && event.Code == 0 {
+			heights[event.Height] = struct{}{}
 		}
+	}
 
-		for _, attr := range event.Attributes {
-			switch tryBase64Decoding(attr.Key) {
-			case "packet_data":
-				data = tryBase64Decoding(attr.Value)
-			case "packet_timeout_height":
-				timeoutHeight = clientTypes.MustParseHeight(tryBase64Decoding(attr.Value)).GetRevisionHeight()
-			case "packet_timeout_timestamp":
-				timeoutTimestamp, err = strconv.ParseInt(tryBase64Decoding(attr.Value), 10, 64)
-				if err != nil {
-					err = errors.Wrapf(err, "failed to parse timeout timestamp: %+v", event.Attributes)
-					logger.Error(err)
+	var mutex sync.Mutex
+	blockTimes := make(map[int64]time.Time, len(heights))
 
-					panic(err)
-				}
+	var g errgroup.Group
+	g.SetLimit(MAX_BLOCK_TIME_REQUESTS)
+	for height := range heights {
+		g.Go(func() error {
+			blockTime, err := c.GetBlockTime(ctx, height)
+			if err != nil {
+				// latencies are skipped without the block time, it's not worth alerting
+				logger.Warn(err)
+				return nil
 			}
-		}
+
+			mutex.Lock()
+			blockTimes[height] = blockTime
+			mutex.Unlock()
+
+			return nil
+		})
 	}
+	_ = g.Wait()
 
-	return resp.Txs[0].TxResult.Code, resp.Txs[0].Hash.String(), data, timeoutHeight, timeoutTimestamp, true, nil
+	for i, event := range events {
+		events[i].BlockTime = blockTimes[event.Height]
+	}
 }
 
-func (c *Client) GetLatestBlockHeight(ctx context.Context) (int64, error) {
-	abciInfo, err := c.rpcClient.ABCIInfo(ctx)
+// GetLatestHeight returns the latest height with the revision number of the chain id and the time of the block,
+// which timeouts of packets to this chain are compared with
+func (c *Client) GetLatestHeight(ctx context.Context) (clientTypes.Height, time.Time, error) {
+	var status *coreTypes.ResultStatus
+	err := c.call(ctx, "GetLatestHeight", func(rpcClient *cmthttp.HTTP) error {
+		var err error
+		status, err = rpcClient.Status(ctx)
+		return err
+	})
 	if err != nil {
-		return 0, errors.Wrap(err, "failed to get ABCI info")
+		return clientTypes.Height{}, time.Time{}, errors.Wrap(err, "failed to get status")
 	}
 
-	return abciInfo.Response.LastBlockHeight, nil
+	// revision number is 0 if the chain id is not in {chain}-{revision} format
+	revisionNumber := clientTypes.ParseChainID(status.NodeInfo.Network)
+	height := clientTypes.NewHeight(revisionNumber, uint64(status.SyncInfo.LatestBlockHeight))
+
+	return height, status.SyncInfo.LatestBlockTime, nil
 }
 
 func (c *Client) Subscribe(ctx context.Context, query string) (<-chan coreTypes.ResultEvent, error) {
-	resultEvent, err := c.rpcClient.Subscribe(ctx, "subscribe", query)
+	// subscription is on the websocket of the connected endpoint
+	if c.connected < 0 {
+		return nil, errors.New("rpc client is not connected")
+	}
+
+	resultEvent, err := c.rpcClients[c.connected].Subscribe(ctx, "subscribe", query, SUBSCRIPTION_CAPACITY)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to subscribe to query: %s", query)
 	}
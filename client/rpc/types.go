@@ -1,6 +1,8 @@
 package rpc
 
 import (
+	"github.com/dlvlabs/ibcmon/client/endpoint"
+	"github.com/dlvlabs/ibcmon/client/retry"
 	"github.com/dlvlabs/ibcmon/logger"
 	"github.com/pkg/errors"
 
@@ -8,22 +10,40 @@ import (
 )
 
 type Client struct {
-	host string
+	pool  *endpoint.Pool
+	retry retry.Policy
 
-	rpcClient *cmthttp.HTTP
+	// client per endpoint, requests are sent through the active one
+	rpcClients []*cmthttp.HTTP
+	// index of the endpoint whose websocket is started by Connect
+	connected int
 }
 
-func New(host string) (*Client, error) {
-	result := &Client{
-		host: host,
-	}
-	client, err := cmthttp.New(result.host, "/websocket")
+func New(hosts []string, retry retry.Policy) (*Client, error) {
+	result, err := newClient(endpoint.NewPool(hosts), retry)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create rpc client")
+		return nil, err
 	}
-	result.rpcClient = client
 
 	logger.Info("RPC client created")
 
 	return result, nil
 }
+
+func newClient(pool *endpoint.Pool, retry retry.Policy) (*Client, error) {
+	result := &Client{
+		pool:      pool,
+		retry:     retry,
+		connected: -1,
+	}
+
+	for i := range pool.Len() {
+		client, err := cmthttp.New(pool.Host(i), "/websocket")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create rpc client")
+		}
+		result.rpcClients = append(result.rpcClients, client)
+	}
+
+	return result, nil
+}
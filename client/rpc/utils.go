@@ -1,6 +1,10 @@
 package rpc
 
-import "encoding/base64"
+import (
+	"encoding/base64"
+
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+)
 
 func tryBase64Decoding(data string) string {
 	decoded, err := base64.StdEncoding.DecodeString(data)
@@ -9,3 +13,13 @@ func tryBase64Decoding(data string) string {
 	}
 	return string(decoded)
 }
+
+// old nodes encode event attributes in base64, values are decoded only if the key was encoded
+// because numeric values like "1234" are also valid base64
+func decodeAttribute(attr abciTypes.EventAttribute) (string, string) {
+	key := tryBase64Decoding(attr.Key)
+	if key == attr.Key {
+		return attr.Key, attr.Value
+	}
+	return key, tryBase64Decoding(attr.Value)
+}
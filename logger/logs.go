@@ -16,10 +16,19 @@ func Warn(msg any) {
 	log.Warn().Msg(message)
 }
 
+// alert type of errors, matched with silences
+const ALERT_ERROR = "error"
+
 func Error(err error) {
-	// send error msg with stack trace to telegram
-	alert.SendTg(fmt.Sprintf("%+v", err))
+	// send error msg with stack trace to alert sinks
+	alertKey := alert.Key{Condition: ALERT_ERROR}
+	alert.Send(alertKey, alert.ERROR, fmt.Sprintf("%+v", err))
+
+	LogError(err)
+}
 
+// LogError only logs the error, for errors alerted by the caller
+func LogError(err error) {
 	log.Error().Stack().Err(err).Msg("")
 }
 
@@ -5,12 +5,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 
 	"github.com/dlvlabs/ibcmon/alert"
 	"github.com/dlvlabs/ibcmon/app"
 	"github.com/dlvlabs/ibcmon/logger"
 	"github.com/dlvlabs/ibcmon/server"
+	"github.com/pkg/errors"
 
 	"github.com/BurntSushi/toml"
 )
@@ -18,7 +21,9 @@ import (
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
-	ctx := context.Background()
+	// the final state is saved by app.Close on SIGINT and SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	cfgPath := flag.String("config", "", "Config file")
 	flag.Parse()
@@ -43,15 +48,31 @@ func main() {
 	}
 
 	title := "ibcmon"
-	tgTitle := fmt.Sprintf("🤖 %s 🤖", title)
-	alert.SetTg(cfg.TG.Enable, tgTitle, cfg.TG.Token, cfg.TG.ChatID)
+	alertTitle := fmt.Sprintf("🤖 %s 🤖", title)
+	alerters, err := cfg.Alerters()
+	if err != nil {
+		panic(err)
+	}
+	alert.SetAlerters(alertTitle, alerters...)
+	alert.SetRules(cfg.Alert.GroupWait, cfg.Alert.ReminderInterval)
 
 	app, error := app.NewApp(ctx, cfg)
 	if error != nil {
 		panic(error)
 	}
 
-	server := server.NewServer(&app.Store, cfg.General.ListenPort, title)
+	err = alert.SetSilences(app.StateStore(), cfg.Silences)
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		if err := app.Close(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	server := server.NewServer(&app.Store, app.EndpointStatuses, cfg.General.ListenPort, cfg.General.AdminToken, title)
 	go func() {
 		if err := server.Run(); err != nil {
 			panic(err)
@@ -59,6 +80,10 @@ func main() {
 	}()
 
 	err = app.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		logger.Info("shutting down")
+		return
+	}
 	if err != nil {
 		logger.Error(err)
 		return
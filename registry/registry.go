@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dlvlabs/ibcmon/logger"
+	"github.com/pkg/errors"
+)
+
+// Registry resolves endpoints of chains from a local clone or snapshot of
+// https://github.com/cosmos/chain-registry, mainnets and testnets are both read.
+type Registry struct {
+	// chainId => endpoints
+	chains map[string]Endpoints
+}
+
+type Endpoints struct {
+	RPCAddrs  []string
+	GRPCAddrs []string
+	TLSConn   bool
+}
+
+// subset of chain.json
+type chainInfo struct {
+	ChainId string `json:"chain_id"`
+	APIs    struct {
+		RPC  []api `json:"rpc"`
+		GRPC []api `json:"grpc"`
+	} `json:"apis"`
+}
+type api struct {
+	Address string `json:"address"`
+}
+
+func New(path string) (*Registry, error) {
+	r := &Registry{
+		chains: make(map[string]Endpoints),
+	}
+
+	for _, dir := range []string{path, filepath.Join(path, "testnets")} {
+		err := r.load(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(r.chains) == 0 {
+		msg := fmt.Sprintf("no chain found in chain registry: %s", path)
+		return nil, errors.New(msg)
+	}
+
+	msg := fmt.Sprintf("loaded %d chains from chain registry", len(r.chains))
+	logger.Info(msg)
+
+	return r, nil
+}
+
+// read <dir>/<chain name>/chain.json
+func (r *Registry) load(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to read chain registry: %s", dir)
+	}
+
+	for _, entry := range entries {
+		// e.g. "_IBC", "_non-cosmos" and ".github"
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), "_") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		f, err := os.ReadFile(filepath.Join(dir, entry.Name(), "chain.json"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to read chain.json of %s", entry.Name())
+		}
+
+		var info chainInfo
+		err = json.Unmarshal(f, &info)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode chain.json of %s", entry.Name())
+		}
+		if info.ChainId == "" || len(info.APIs.RPC) == 0 || len(info.APIs.GRPC) == 0 {
+			continue
+		}
+
+		var endpoints Endpoints
+		for _, rpc := range info.APIs.RPC {
+			endpoints.RPCAddrs = append(endpoints.RPCAddrs, rpc.Address)
+		}
+		// tls_conn is shared by every grpc endpoint of a chain, so it follows the first one
+		_, endpoints.TLSConn = grpcHost(info.APIs.GRPC[0].Address)
+		for _, grpc := range info.APIs.GRPC {
+			grpcAddr, tlsConn := grpcHost(grpc.Address)
+			if tlsConn == endpoints.TLSConn {
+				endpoints.GRPCAddrs = append(endpoints.GRPCAddrs, grpcAddr)
+			}
+		}
+		r.chains[info.ChainId] = endpoints
+	}
+
+	return nil
+}
+
+func (r *Registry) Endpoints(chainId string) (Endpoints, bool) {
+	endpoints, ok := r.chains[chainId]
+	return endpoints, ok
+}
+
+// grpc addresses in the registry are written either as "host:port" or as url,
+// e.g. "grpc.osmosis.zone:9090" or "https://grpc.osmosis.zone:443"
+func grpcHost(address string) (string, bool) {
+	if !strings.Contains(address, "://") {
+		return address, strings.HasSuffix(address, ":443")
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return address, false
+	}
+
+	tlsConn := u.Scheme == "https" || u.Port() == "443"
+	if u.Port() != "" {
+		return u.Host, tlsConn
+	}
+	if tlsConn {
+		return u.Host + ":443", tlsConn
+	}
+	return u.Host + ":80", tlsConn
+}
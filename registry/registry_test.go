@@ -0,0 +1,158 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGrpcHost(t *testing.T) {
+	tests := []struct {
+		address string
+
+		expectedHost    string
+		expectedTLSConn bool
+	}{
+		{address: "grpc.osmosis.zone:9090", expectedHost: "grpc.osmosis.zone:9090", expectedTLSConn: false},
+		{address: "grpc.osmosis.zone:443", expectedHost: "grpc.osmosis.zone:443", expectedTLSConn: true},
+		{address: "https://grpc.osmosis.zone", expectedHost: "grpc.osmosis.zone:443", expectedTLSConn: true},
+		{address: "https://grpc.osmosis.zone:9443", expectedHost: "grpc.osmosis.zone:9443", expectedTLSConn: true},
+		{address: "http://grpc.osmosis.zone", expectedHost: "grpc.osmosis.zone:80", expectedTLSConn: false},
+		{address: "tcp://grpc.osmosis.zone:443", expectedHost: "grpc.osmosis.zone:443", expectedTLSConn: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+			host, tlsConn := grpcHost(test.address)
+			if host != test.expectedHost || tlsConn != test.expectedTLSConn {
+				t.Fatalf("expected %s %t, got %s %t", test.expectedHost, test.expectedTLSConn, host, tlsConn)
+			}
+		})
+	}
+}
+
+// write <path>/<name>/chain.json
+func writeChain(t *testing.T, path, name, chainJson string) {
+	t.Helper()
+
+	dir := filepath.Join(path, name)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "chain.json"), []byte(chainJson), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	path := t.TempDir()
+	writeChain(t, path, "osmosis", `{
+		"chain_id": "osmosis-1",
+		"apis": {
+			"rpc": [{"address": "https://rpc.osmosis.zone"}],
+			"grpc": [{"address": "grpc.osmosis.zone:443"}]
+		}
+	}`)
+	writeChain(t, path, "noble", `{
+		"chain_id": "noble-1",
+		"apis": {
+			"rpc": [{"address": "https://rpc.noble.xyz"}],
+			"grpc": [{"address": "grpc.noble.xyz:9090"}]
+		}
+	}`)
+	// chains without grpc can't be monitored
+	writeChain(t, path, "nogrpc", `{
+		"chain_id": "nogrpc-1",
+		"apis": {
+			"rpc": [{"address": "https://rpc.nogrpc.xyz"}]
+		}
+	}`)
+	writeChain(t, path, "_IBC", `{"chain_id": "ibc-1"}`)
+	writeChain(t, filepath.Join(path, "testnets"), "osmosistestnet", `{
+		"chain_id": "osmo-test-5",
+		"apis": {
+			"rpc": [{"address": "https://rpc.testnet.osmosis.zone"}],
+			"grpc": [{"address": "grpc.testnet.osmosis.zone:443"}]
+		}
+	}`)
+
+	r, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		chainId string
+
+		found           bool
+		expectedTLSConn bool
+	}{
+		{chainId: "osmosis-1", found: true, expectedTLSConn: true},
+		{chainId: "noble-1", found: true, expectedTLSConn: false},
+		{chainId: "osmo-test-5", found: true, expectedTLSConn: true},
+		{chainId: "nogrpc-1", found: false},
+		{chainId: "ibc-1", found: false},
+		{chainId: "unknown-1", found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.chainId, func(t *testing.T) {
+			endpoints, found := r.Endpoints(test.chainId)
+			if found != test.found {
+				t.Fatalf("expected found %t, got %t", test.found, found)
+			}
+			if endpoints.TLSConn != test.expectedTLSConn {
+				t.Fatalf("expected tls_conn %t, got %t", test.expectedTLSConn, endpoints.TLSConn)
+			}
+		})
+	}
+}
+
+func TestNewEmpty(t *testing.T) {
+	_, err := New(t.TempDir())
+	if err == nil {
+		t.Fatal("expected error for chain registry without chains")
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	path := t.TempDir()
+	writeChain(t, path, "osmosis", `{
+		"chain_id": "osmosis-1",
+		"apis": {
+			"rpc": [
+				{"address": "https://rpc.osmosis.zone"},
+				{"address": "https://osmosis-rpc.polkachu.com"}
+			],
+			"grpc": [
+				{"address": "grpc.osmosis.zone:443"},
+				{"address": "osmosis-grpc.polkachu.com:12590"},
+				{"address": "https://grpc.osmosis.example"}
+			]
+		}
+	}`)
+
+	r, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, found := r.Endpoints("osmosis-1")
+	if !found {
+		t.Fatal("expected osmosis-1 to be found")
+	}
+
+	expected := Endpoints{
+		RPCAddrs: []string{"https://rpc.osmosis.zone", "https://osmosis-rpc.polkachu.com"},
+		// grpc endpoints without tls are dropped since tls_conn follows the first one
+		GRPCAddrs: []string{"grpc.osmosis.zone:443", "grpc.osmosis.example:443"},
+		TLSConn:   true,
+	}
+	if !slices.Equal(endpoints.RPCAddrs, expected.RPCAddrs) ||
+		!slices.Equal(endpoints.GRPCAddrs, expected.GRPCAddrs) ||
+		endpoints.TLSConn != expected.TLSConn {
+		t.Fatalf("expected %+v, got %+v", expected, endpoints)
+	}
+}
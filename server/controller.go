@@ -1,8 +1,13 @@
 package server
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/dlvlabs/ibcmon/alert"
+	"github.com/pkg/errors"
 )
 
 func (server *Server) getIBCInfo(w http.ResponseWriter, r *http.Request) {
@@ -34,3 +39,114 @@ func (server *Server) getIBCPacket(w http.ResponseWriter, r *http.Request) {
 
 	return
 }
+
+func (server *Server) getUnrelayedPackets(w http.ResponseWriter, r *http.Request) {
+	resp := server.QueryUnrelayedPackets()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(resp)
+
+	return
+}
+
+func (server *Server) getEndpoints(w http.ResponseWriter, r *http.Request) {
+	resp := server.QueryEndpoints()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(resp)
+
+	return
+}
+
+func (server *Server) getChangelog(w http.ResponseWriter, r *http.Request) {
+	resp := server.QueryChangelog()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(resp)
+
+	return
+}
+
+func (server *Server) getRelayers(w http.ResponseWriter, r *http.Request) {
+	resp := server.QueryRelayers()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(resp)
+
+	return
+}
+
+func (server *Server) getSilences(w http.ResponseWriter, r *http.Request) {
+	resp := alert.Silences()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(resp)
+
+	return
+}
+
+func (server *Server) createSilence(w http.ResponseWriter, r *http.Request) {
+	var silence alert.Silence
+	err := json.NewDecoder(r.Body).Decode(&silence)
+	if err != nil {
+		writeError(w, 400, err)
+		return
+	}
+
+	resp, err := alert.AddSilence(silence)
+	if err != nil {
+		writeError(w, 400, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+	json.NewEncoder(w).Encode(resp)
+
+	return
+}
+
+func (server *Server) deleteSilence(w http.ResponseWriter, r *http.Request) {
+	found, err := alert.DeleteSilence(r.PathValue("id"))
+	if err != nil {
+		writeError(w, 500, err)
+		return
+	}
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+
+	w.WriteHeader(204)
+
+	return
+}
+
+// authorize requires "Authorization: Bearer <admin_token>", every request is rejected if admin_token is empty
+func (server *Server) authorize(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if server.adminToken == "" {
+			writeError(w, 403, errors.New("admin_token is not configured"))
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(server.adminToken)) != 1 {
+			writeError(w, 401, errors.New("invalid token"))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
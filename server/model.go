@@ -1,27 +1,82 @@
 package server
 
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/dlvlabs/ibcmon/app"
+)
+
 func (server *Server) QueryIBCInfo() IBCInfos {
-	ibcInfos := make(IBCInfos, 0, len(server.Store.IBCInfo))
+	snapshot := server.Store.Snapshot()
+	ibcInfos := make(IBCInfos, 0, len(snapshot.IBCInfo))
 
-	for chainId, clients := range server.Store.IBCInfo {
+	for chainId, clients := range snapshot.IBCInfo {
 		for clientId, client := range clients {
-			for connectionId, channels := range client.Connections {
-				for channelId, channel := range channels {
-					source := newIBC(
-						chainId, clientId, connectionId,
-						channelId, channel.PortId,
-					)
-					destination := newIBC(
-						client.ChainId, channel.Counterparty.ClientId, channel.Counterparty.ConnectionId,
-						channel.Counterparty.ChannelId, channel.Counterparty.PortId,
-					)
-					ibcInfos = append(ibcInfos, IBCInfo{
-						Updated: server.Store.Updated,
-
-						Source:      source,
-						Destination: destination,
-					})
+			for _, path := range client.Paths(clientId) {
+				connectionId, channelId, channel := path.ConnectionId, path.ChannelId, path.Channel
+
+				source := newIBC(
+					chainId, clientId, connectionId,
+					channelId, channel.PortId,
+				)
+				source.ClientType = client.ClientType
+				destination := newIBC(
+					client.ChainId, channel.Counterparty.ClientId, channel.Counterparty.ConnectionId,
+					channel.Counterparty.ChannelId, channel.Counterparty.PortId,
+				)
+				if counterparty, ok := snapshot.IBCInfo[client.ChainId][channel.Counterparty.ClientId]; ok {
+					destination.ClientType = counterparty.ClientType
 				}
+
+				var flushingSince *time.Time
+				if !channel.FlushingSince.IsZero() {
+					flushingSince = &channel.FlushingSince
+				}
+
+				ibcInfos = append(ibcInfos, IBCInfo{
+					Updated:     snapshot.Updated,
+					BaseChainId: snapshot.BaseChainId(chainId, client.ChainId),
+
+					Unmonitored: client.Unmonitored,
+
+					ConnectionState: client.ConnectionStates[connectionId],
+					ChannelState:    channel.GetState(),
+					FlushingSince:   flushingSince,
+
+					Source:      source,
+					Destination: destination,
+				})
+			}
+
+			// connections in handshake have no channel
+			for connectionId, state := range client.ConnectionStates {
+				if state == app.STATE_OPEN {
+					continue
+				}
+
+				source := IBC{
+					Path: fmt.Sprintf("%s(%s/%s)", chainId, clientId, connectionId),
+
+					ChainId:      chainId,
+					ClientId:     clientId,
+					ClientType:   client.ClientType,
+					ConnectionId: connectionId,
+				}
+				ibcInfos = append(ibcInfos, IBCInfo{
+					Updated:     snapshot.Updated,
+					BaseChainId: snapshot.BaseChainId(chainId, client.ChainId),
+
+					Unmonitored: client.Unmonitored,
+
+					ConnectionState: state,
+
+					Source:      source,
+					Destination: IBC{Path: client.ChainId, ChainId: client.ChainId},
+				})
 			}
 		}
 	}
@@ -30,12 +85,30 @@ func (server *Server) QueryIBCInfo() IBCInfos {
 }
 
 func (server *Server) QueryClientHealth() ClientHealths {
-	clientHealths := make(ClientHealths, 0, len(server.Store.IBCInfo))
+	snapshot := server.Store.Snapshot()
+	clientHealths := make(ClientHealths, 0, len(snapshot.IBCInfo))
 
-	for chainId, clients := range server.Store.IBCInfo {
+	for chainId, clients := range snapshot.IBCInfo {
 		for clientId, client := range clients {
+			var nextExpectedUpdate *time.Time
+			if nextUpdate, ok := client.NextExpectedUpdate(); ok {
+				nextExpectedUpdate = &nextUpdate
+			}
+
+			var expiresAt *time.Time
+			var timeToExpiry float64
+			if client.Expires() && !client.ClientUpdated.IsZero() {
+				expiry := client.ExpiresAt()
+				expiresAt = &expiry
+				timeToExpiry = client.TimeToExpiry().Seconds()
+			}
+
 			clientHealths = append(clientHealths, ClientHealth{
+				BaseChainId: snapshot.BaseChainId(chainId, client.ChainId),
+
 				Health:        client.Health,
+				Status:        client.Status,
+				ClientType:    client.ClientType,
 				ClientUpdated: client.ClientUpdated,
 
 				Source:      chainId,
@@ -43,6 +116,15 @@ func (server *Server) QueryClientHealth() ClientHealths {
 
 				ClientId:       clientId,
 				TrustingPeriod: client.TrustingPeriod.Seconds(),
+
+				ExpiresAt:          expiresAt,
+				TimeToExpiry:       timeToExpiry,
+				TimeSinceUpdate:    client.TimeSinceUpdate().Seconds(),
+				UpdateInterval:     client.UpdateInterval.Seconds(),
+				NextExpectedUpdate: nextExpectedUpdate,
+				ExpiryForecast:     client.ExpiryForecast,
+
+				Degraded: newDegradation(client.Degraded),
 			})
 		}
 	}
@@ -51,51 +133,328 @@ func (server *Server) QueryClientHealth() ClientHealths {
 }
 
 func (server *Server) QueryIBCPacket() IBCPackets {
-	ibcPackets := make(IBCPackets, 0, len(server.Store.IBCInfo))
+	snapshot := server.Store.Snapshot()
+	ibcPackets := make(IBCPackets, 0, len(snapshot.IBCInfo))
 
-	for chainId, clients := range server.Store.IBCInfo {
+	for chainId, clients := range snapshot.IBCInfo {
 		for clientId, client := range clients {
-			for connectionId, channels := range client.Connections {
-				for channelId, channel := range channels {
-					// exception handling for chains not support `NextSequenceSend` query
-					if channel.IBCPacketTracker == nil {
-						continue
+			for _, path := range client.Paths(clientId) {
+				connectionId, channelId, channel := path.ConnectionId, path.ChannelId, path.Channel
+
+				// tracker is not started yet
+				if channel.IBCPacketTracker == nil {
+					continue
+				}
+
+				tracker := channel.IBCPacketTracker
+
+				latestSucceedPackets := make(map[string]SucceedPacket)
+				for packetType, succeedPacket := range tracker.LatestSucceedPackets {
+					latestSucceedPackets[packetType] = SucceedPacket{
+						Hash:     succeedPacket.Hash,
+						Sequence: succeedPacket.Sequence,
+						Data:     succeedPacket.Data,
+						AckError: succeedPacket.AckError,
 					}
+				}
 
-					latestSucceedPackets := make(map[string]SucceedPacket)
-					for packetType, succeedPacket := range channel.IBCPacketTracker.LatestSucceedPackets {
-						latestSucceedPackets[packetType] = SucceedPacket{
-							Hash:     succeedPacket.Hash,
-							Sequence: succeedPacket.Sequence,
-							Data:     succeedPacket.Data,
-						}
+				now := time.Now().UTC()
+				var oldestPendingAge float64 = 0
+				waitingTimeout := 0
+				inFlightPackets := make([]InFlightPacket, 0, len(tracker.InFlightPackets))
+				for _, inFlightPacket := range tracker.GetInFlightPackets() {
+					age := now.Sub(inFlightPacket.Sent).Seconds()
+					if age > oldestPendingAge {
+						oldestPendingAge = age
 					}
 
-					source := newIBC(
-						chainId, clientId, connectionId,
-						channelId, channel.PortId,
-					)
-					destination := newIBC(
-						client.ChainId, channel.Counterparty.ClientId, channel.Counterparty.ConnectionId,
-						channel.Counterparty.ChannelId, channel.Counterparty.PortId,
-					)
-					ibcPackets = append(ibcPackets, IBCPacket{
-						Updated: channel.IBCPacketTracker.Updated,
+					var timedOut *time.Time
+					if inFlightPacket.PacketType == app.PACKET_STATUS_TIMEOUT {
+						waitingTimeout++
+						timedOut = &inFlightPacket.TimedOut
+					}
 
-						Health: channel.IBCPacketTracker.Health,
+					inFlightPackets = append(inFlightPackets, InFlightPacket{
+						Sequence:   inFlightPacket.Sequence,
+						WaitingFor: inFlightPacket.PacketType.String(),
+						Sent:       inFlightPacket.Sent,
+						Age:        age,
+						TimedOut:   timedOut,
+					})
+				}
 
-						Source:      source,
-						Destination: destination,
+				relayedPackets := make([]RelayedPacket, 0, len(tracker.RelayedPackets))
+				latencies := make(map[string][]float64)
+				for _, relayedPacket := range tracker.RelayedPackets {
+					relayedPackets = append(relayedPackets, RelayedPacket{
+						Sequence:    relayedPacket.Sequence,
+						Latency:     relayedPacket.Latency.Seconds(),
+						RecvLatency: relayedPacket.RecvLatency.Seconds(),
+						AckLatency:  relayedPacket.AckLatency.Seconds(),
+						RecvRelayer: relayedPacket.RecvRelayer,
+						AckRelayer:  relayedPacket.AckRelayer,
+					})
 
-						Sequence:          channel.IBCPacketTracker.Sequence,
-						ConsecutiveMissed: channel.IBCPacketTracker.MissedCnt,
+					// latencies are 0 if the block times are unknown, or the packet is relayed before the phases are recorded
+					phases := map[string]time.Duration{
+						app.LATENCY_SEND_TO_ACK:  relayedPacket.Latency,
+						app.LATENCY_SEND_TO_RECV: relayedPacket.RecvLatency,
+						app.LATENCY_RECV_TO_ACK:  relayedPacket.AckLatency,
+					}
+					for phase, phaseLatency := range phases {
+						if phaseLatency == 0 {
+							continue
+						}
+						latencies[phase] = append(latencies[phase], phaseLatency.Seconds())
+					}
+				}
 
-						LatestSucceedPackets: latestSucceedPackets,
-					})
+				latency := make(map[string]LatencyPercentiles)
+				for phase, values := range latencies {
+					latency[phase] = newLatencyPercentiles(values)
 				}
+
+				source := newIBC(
+					chainId, clientId, connectionId,
+					channelId, channel.PortId,
+				)
+				destination := newIBC(
+					client.ChainId, channel.Counterparty.ClientId, channel.Counterparty.ConnectionId,
+					channel.Counterparty.ChannelId, channel.Counterparty.PortId,
+				)
+				ibcPackets = append(ibcPackets, IBCPacket{
+					Updated:     tracker.Updated,
+					BaseChainId: snapshot.BaseChainId(chainId, client.ChainId),
+
+					Health: tracker.Health,
+
+					Source:      source,
+					Destination: destination,
+
+					Sequence:          tracker.Sequence,
+					SequenceSource:    tracker.SequenceSource,
+					ConsecutiveMissed: tracker.MissedCnt,
+
+					LatestSucceedPackets: latestSucceedPackets,
+
+					Relayed:  tracker.RelayedCnt,
+					TimedOut: tracker.TimedOutCnt,
+					Failed:   tracker.FailedCnt,
+					ErrorAck: tracker.ErrorAckCnt,
+
+					Pending:          len(inFlightPackets),
+					OldestPendingAge: oldestPendingAge,
+					WaitingTimeout:   waitingTimeout,
+					InFlightPackets:  inFlightPackets,
+					RelayedPackets:   relayedPackets,
+					Latency:          latency,
+
+					LatencyHistograms: tracker.Latencies,
+
+					Degraded: newDegradation(tracker.Degraded),
+				})
 			}
 		}
 	}
 
 	return ibcPackets
 }
+
+// percentiles of the latencies by nearest rank
+func newLatencyPercentiles(latencies []float64) LatencyPercentiles {
+	slices.Sort(latencies)
+
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p*float64(len(latencies)))) - 1
+		return latencies[max(rank, 0)]
+	}
+
+	return LatencyPercentiles{
+		P50: percentile(0.50),
+		P95: percentile(0.95),
+	}
+}
+
+func (server *Server) QueryUnrelayedPackets() UnrelayedPackets {
+	snapshot := server.Store.Snapshot()
+	unrelayedPackets := make(UnrelayedPackets, 0, len(snapshot.IBCInfo))
+
+	for chainId, clients := range snapshot.IBCInfo {
+		for clientId, client := range clients {
+			for _, path := range client.Paths(clientId) {
+				connectionId, channelId, channel := path.ConnectionId, path.ChannelId, path.Channel
+
+				unrelayed := channel.Unrelayed
+				if unrelayed == nil {
+					continue
+				}
+
+				source := newIBC(
+					chainId, clientId, connectionId,
+					channelId, channel.PortId,
+				)
+				destination := newIBC(
+					client.ChainId, channel.Counterparty.ClientId, channel.Counterparty.ConnectionId,
+					channel.Counterparty.ChannelId, channel.Counterparty.PortId,
+				)
+				unrelayedPackets = append(unrelayedPackets, UnrelayedPacket{
+					Updated:     unrelayed.Updated,
+					BaseChainId: snapshot.BaseChainId(chainId, client.ChainId),
+
+					Health: unrelayed.Health,
+
+					Source:      source,
+					Destination: destination,
+
+					UnreceivedPackets: unrelayed.UnreceivedPackets,
+					UnreceivedAcks:    unrelayed.UnreceivedAcks,
+					OldestSequence:    unrelayed.OldestSequence,
+					OldestSince:       unrelayed.OldestSince,
+
+					Degraded: newDegradation(unrelayed.Degraded),
+				})
+			}
+		}
+	}
+
+	return unrelayedPackets
+}
+
+// newest change first
+func (server *Server) QueryChangelog() Changelog {
+	snapshot := server.Store.Snapshot()
+	changelog := make(Changelog, 0, len(snapshot.Changelog))
+
+	for i := len(snapshot.Changelog) - 1; i >= 0; i-- {
+		change := snapshot.Changelog[i]
+
+		source := IBC{
+			ChainId:      change.ChainId,
+			ClientId:     change.ClientId,
+			ConnectionId: change.ConnectionId,
+			ChannelId:    change.ChannelId,
+			PortId:       change.PortId,
+		}
+		switch {
+		case change.ChannelId != "" && change.ConnectionId != "":
+			source = newIBC(change.ChainId, change.ClientId, change.ConnectionId, change.ChannelId, change.PortId)
+		case change.ConnectionId != "":
+			source.Path = fmt.Sprintf("%s(%s/%s)", change.ChainId, change.ClientId, change.ConnectionId)
+		default:
+			// clients and IBC v2 paths
+			source.Path = fmt.Sprintf("%s(%s)", change.ChainId, change.ClientId)
+		}
+
+		changelog = append(changelog, TopologyChange{
+			Time:        change.Time,
+			BaseChainId: snapshot.BaseChainId(change.ChainId, change.CounterpartyChainId),
+
+			Type: change.Type,
+
+			Source:      source,
+			Destination: change.CounterpartyChainId,
+
+			PrevState: change.PrevState,
+			State:     change.State,
+		})
+	}
+
+	return changelog
+}
+
+func (server *Server) QueryEndpoints() Endpoints {
+	statuses := server.EndpointStatuses()
+	endpoints := make(Endpoints, 0, len(statuses))
+
+	for _, status := range statuses {
+		endpoints = append(endpoints, Endpoint{
+			ChainId: status.ChainId,
+			Type:    status.Type,
+			Host:    status.Host,
+
+			Active:  status.Active,
+			Healthy: status.Healthy,
+			Latency: status.Latency.Seconds(),
+
+			Requests: status.Requests,
+			Errors:   status.Errors,
+			Retries:  status.Retries,
+		})
+	}
+
+	return endpoints
+}
+
+// relayers of every tracked channel aggregated per chain and address, sorted by relayed packets
+func (server *Server) QueryRelayers() Relayers {
+	snapshot := server.Store.Snapshot()
+
+	type aggregated struct {
+		Relayer
+		latencySum time.Duration
+		latencyCnt uint64
+	}
+	relayers := make(map[string]*aggregated)
+
+	for _, clients := range snapshot.IBCInfo {
+		for clientId, client := range clients {
+			for _, path := range client.Paths(clientId) {
+				tracker := path.Channel.IBCPacketTracker
+				if tracker == nil {
+					continue
+				}
+
+				for key, stat := range tracker.Relayers {
+					relayer, ok := relayers[key]
+					if !ok {
+						relayer = &aggregated{Relayer: Relayer{
+							ChainId: stat.ChainId,
+							Address: stat.Address,
+							Paths:   []string{},
+						}}
+						relayers[key] = relayer
+					}
+
+					relayer.RecvPackets += stat.RecvCnt
+					relayer.AckPackets += stat.AckCnt
+					relayer.TimeoutPackets += stat.TimeoutCnt
+					relayer.FailedTxs += stat.FailedCnt
+					relayer.latencySum += stat.LatencySum
+					relayer.latencyCnt += stat.LatencyCnt
+
+					// memo of the latest tx among the channels
+					if stat.Memo != "" && (relayer.Memo == "" || stat.LastRelayed.After(relayer.LastRelayed)) {
+						relayer.Memo = stat.Memo
+					}
+					if stat.LastRelayed.After(relayer.LastRelayed) {
+						relayer.LastRelayed = stat.LastRelayed
+					}
+
+					relayer.Paths = append(relayer.Paths, tracker.String())
+				}
+			}
+		}
+	}
+
+	result := make(Relayers, 0, len(relayers))
+	for _, relayer := range relayers {
+		if relayer.latencyCnt > 0 {
+			relayer.AverageLatency = relayer.latencySum.Seconds() / float64(relayer.latencyCnt)
+		}
+		slices.Sort(relayer.Paths)
+
+		result = append(result, relayer.Relayer)
+	}
+
+	relayed := func(relayer Relayer) uint64 {
+		return relayer.RecvPackets + relayer.AckPackets + relayer.TimeoutPackets
+	}
+	slices.SortFunc(result, func(a, b Relayer) int {
+		if relayed(a) != relayed(b) {
+			return cmp.Compare(relayed(b), relayed(a))
+		}
+		return cmp.Compare(a.ChainId+a.Address, b.ChainId+b.Address)
+	})
+
+	return result
+}
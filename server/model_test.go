@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestNewLatencyPercentiles(t *testing.T) {
+	tests := []struct {
+		name string
+
+		latencies []float64
+
+		expected LatencyPercentiles
+	}{
+		{
+			name:      "one latency",
+			latencies: []float64{7},
+			expected:  LatencyPercentiles{P50: 7, P95: 7},
+		},
+		{
+			name:      "unsorted",
+			latencies: []float64{30, 10, 20, 40},
+			expected:  LatencyPercentiles{P50: 20, P95: 40},
+		},
+		{
+			name:      "nearest rank",
+			latencies: []float64{20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			expected:  LatencyPercentiles{P50: 10, P95: 19},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := newLatencyPercentiles(test.latencies)
+			if result != test.expected {
+				t.Fatalf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
@@ -1,31 +1,58 @@
 package server
 
 import (
+	"github.com/dlvlabs/ibcmon/app"
+
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cosmos/ibc-go/v10/modules/core/exported"
 )
 
 type IBCInfoCollector struct {
 	server *Server
 
-	Up *prometheus.Desc
+	Up           *prometheus.Desc
+	Monitored    *prometheus.Desc
+	ChannelState *prometheus.Desc
+}
+
+var CHANNEL_STATES = []string{
+	app.STATE_INIT,
+	app.STATE_TRYOPEN,
+	app.STATE_OPEN,
+	app.STATE_CLOSED,
+	app.STATE_FLUSHING,
+	app.STATE_FLUSHCOMPLETE,
 }
 
 func newIBCInfoCollector(server *Server) *IBCInfoCollector {
-	labels := []string{"src_chain_id", "src_path", "dst_chain_id", "dst_path"}
+	labels := []string{"base_chain_id", "src_chain_id", "src_path", "dst_chain_id", "dst_path"}
 
 	return &IBCInfoCollector{
 		server: server,
 
 		Up: prometheus.NewDesc(
 			server.MetricPrefix+"_ibc_tao_up",
-			"If 1 client, connection, channel is normal",
+			"If 1 client, connection, channel is normal, 0 if the connection or channel is not open or upgrading",
 			labels, nil,
 		),
+		Monitored: prometheus.NewDesc(
+			server.MetricPrefix+"_ibc_tao_monitored",
+			"If 1 packets are tracked, 0 if endpoints of the destination are not found",
+			labels, nil,
+		),
+		ChannelState: prometheus.NewDesc(
+			server.MetricPrefix+"_channel_state",
+			"If 1 the channel is in the state",
+			append(labels, "state"), nil,
+		),
 	}
 }
 
 func (c *IBCInfoCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.Up
+	ch <- c.Monitored
+	ch <- c.ChannelState
 }
 
 func (c *IBCInfoCollector) Collect(ch chan<- prometheus.Metric) {
@@ -33,30 +60,78 @@ func (c *IBCInfoCollector) Collect(ch chan<- prometheus.Metric) {
 
 	for _, ibcInfo := range resp {
 		labels := []string{
+			ibcInfo.BaseChainId,
 			ibcInfo.Source.ChainId,
 			ibcInfo.Source.Path,
 			ibcInfo.Destination.ChainId,
 			ibcInfo.Destination.Path,
 		}
 
-		var up float64 = 1
+		var up float64 = 0
+		switch ibcInfo.ChannelState {
+		case app.STATE_OPEN, app.STATE_FLUSHING, app.STATE_FLUSHCOMPLETE:
+			up = 1
+		}
 		ch <- prometheus.MustNewConstMetric(
 			c.Up,
 			prometheus.GaugeValue,
 			up,
 			labels...,
 		)
+
+		var monitored float64 = 1
+		if ibcInfo.Unmonitored {
+			monitored = 0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Monitored,
+			prometheus.GaugeValue,
+			monitored,
+			labels...,
+		)
+
+		// connections in handshake have no channel
+		if ibcInfo.ChannelState == "" {
+			continue
+		}
+		for _, state := range CHANNEL_STATES {
+			var current float64 = 0
+			if ibcInfo.ChannelState == state {
+				current = 1
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				c.ChannelState,
+				prometheus.GaugeValue,
+				current,
+				append(labels, state)...,
+			)
+		}
 	}
 }
 
+var CLIENT_STATUSES = []string{
+	exported.Active.String(),
+	exported.Expired.String(),
+	exported.Frozen.String(),
+	exported.Unknown.String(),
+	exported.Unauthorized.String(),
+}
+
 type ClientHealthCollector struct {
 	server *Server
 
-	Health *prometheus.Desc
+	Health          *prometheus.Desc
+	Status          *prometheus.Desc
+	TimeToExpiry    *prometheus.Desc
+	TimeSinceUpdate *prometheus.Desc
+	UpdateInterval  *prometheus.Desc
+	ExpiryForecast  *prometheus.Desc
+	Degraded        *prometheus.Desc
 }
 
 func newClientHealthCollector(server *Server) *ClientHealthCollector {
-	labels := []string{"src_chain_id", "dst_chain_id", "client_id", "updated_at"}
+	labels := []string{"base_chain_id", "src_chain_id", "dst_chain_id", "client_id"}
 
 	return &ClientHealthCollector{
 		server: server,
@@ -66,11 +141,47 @@ func newClientHealthCollector(server *Server) *ClientHealthCollector {
 			"Health status of the ibc client",
 			labels, nil,
 		),
+		Status: prometheus.NewDesc(
+			server.MetricPrefix+"_client_status",
+			"1 for the current status of the ibc client, 0 for the others",
+			append(labels, "status"), nil,
+		),
+		TimeToExpiry: prometheus.NewDesc(
+			server.MetricPrefix+"_client_time_to_expiry_seconds",
+			"Seconds until the ibc client is expired, negative if expired",
+			labels, nil,
+		),
+		TimeSinceUpdate: prometheus.NewDesc(
+			server.MetricPrefix+"_client_time_since_update_seconds",
+			"Seconds since the latest consensus state of the ibc client",
+			labels, nil,
+		),
+		UpdateInterval: prometheus.NewDesc(
+			server.MetricPrefix+"_client_update_interval_seconds",
+			"Moving average of observed intervals between ibc client updates",
+			labels, nil,
+		),
+		ExpiryForecast: prometheus.NewDesc(
+			server.MetricPrefix+"_client_expiry_forecast",
+			"1 if the ibc client would be expired before the next expected update",
+			labels, nil,
+		),
+		Degraded: prometheus.NewDesc(
+			server.MetricPrefix+"_client_degraded",
+			"1 if checking the ibc client fails, the other values are of the last success",
+			labels, nil,
+		),
 	}
 }
 
 func (c *ClientHealthCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.Health
+	ch <- c.Status
+	ch <- c.TimeToExpiry
+	ch <- c.TimeSinceUpdate
+	ch <- c.UpdateInterval
+	ch <- c.ExpiryForecast
+	ch <- c.Degraded
 }
 
 func (c *ClientHealthCollector) Collect(ch chan<- prometheus.Metric) {
@@ -78,12 +189,19 @@ func (c *ClientHealthCollector) Collect(ch chan<- prometheus.Metric) {
 
 	for _, clientHealth := range resp {
 		labels := []string{
+			clientHealth.BaseChainId,
 			clientHealth.Source,
 			clientHealth.Destination,
 			clientHealth.ClientId,
-			clientHealth.ClientUpdated.String(),
 		}
 
+		ch <- prometheus.MustNewConstMetric(
+			c.Degraded,
+			prometheus.GaugeValue,
+			degraded(clientHealth.Degraded),
+			labels...,
+		)
+
 		var health float64 = 0
 		if clientHealth.Health {
 			health = 1
@@ -95,6 +213,70 @@ func (c *ClientHealthCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(health),
 			labels...,
 		)
+
+		for _, status := range CLIENT_STATUSES {
+			var current float64 = 0
+			if clientHealth.Status == status {
+				current = 1
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				c.Status,
+				prometheus.GaugeValue,
+				current,
+				append(labels, status)...,
+			)
+		}
+
+		// not checked yet, or the client type is checked with its status only
+		if clientHealth.ClientUpdated.IsZero() {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.TimeSinceUpdate,
+			prometheus.GaugeValue,
+			clientHealth.TimeSinceUpdate,
+			labels...,
+		)
+
+		if clientHealth.NextExpectedUpdate != nil {
+			ch <- prometheus.MustNewConstMetric(
+				c.UpdateInterval,
+				prometheus.GaugeValue,
+				clientHealth.UpdateInterval,
+				labels...,
+			)
+		}
+
+		// the client type doesn't expire by time
+		if clientHealth.ExpiresAt == nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.TimeToExpiry,
+			prometheus.GaugeValue,
+			clientHealth.TimeToExpiry,
+			labels...,
+		)
+
+		// update cadence is not observed yet
+		if clientHealth.NextExpectedUpdate == nil {
+			continue
+		}
+
+		var expiryForecast float64 = 0
+		if clientHealth.ExpiryForecast {
+			expiryForecast = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.ExpiryForecast,
+			prometheus.GaugeValue,
+			expiryForecast,
+			labels...,
+		)
 	}
 }
 
@@ -106,10 +288,20 @@ type IBCPacketCollector struct {
 	ObservedSucceedSendPacketSequence *prometheus.Desc
 	ObservedSucceedRecvPacketSequence *prometheus.Desc
 	ObservedSucceedAckPacketSequence  *prometheus.Desc
+	PendingPackets                    *prometheus.Desc
+	OldestPendingPacketAge            *prometheus.Desc
+	LatestRelayedPacketLatency        *prometheus.Desc
+	RelayLatency                      *prometheus.Desc
+	RelayedPackets                    *prometheus.Desc
+	TimedOutPackets                   *prometheus.Desc
+	FailedPackets                     *prometheus.Desc
+	ErrorAckPackets                   *prometheus.Desc
+	WaitingTimeoutPackets             *prometheus.Desc
+	Degraded                          *prometheus.Desc
 }
 
 func newIBCPacketCollector(server *Server) *IBCPacketCollector {
-	labels := []string{"src_chain_id", "src_path", "dst_chain_id", "dst_path"}
+	labels := []string{"base_chain_id", "src_chain_id", "src_path", "dst_chain_id", "dst_path"}
 
 	return &IBCPacketCollector{
 		server: server,
@@ -139,6 +331,56 @@ func newIBCPacketCollector(server *Server) *IBCPacketCollector {
 			"Sequence number of the last successfully acknowledged packet",
 			labels, nil,
 		),
+		PendingPackets: prometheus.NewDesc(
+			server.MetricPrefix+"_pending_packets",
+			"Number of sent packets waiting for recv or ack",
+			labels, nil,
+		),
+		OldestPendingPacketAge: prometheus.NewDesc(
+			server.MetricPrefix+"_oldest_pending_packet_age_seconds",
+			"Seconds since the oldest pending packet was sent",
+			labels, nil,
+		),
+		LatestRelayedPacketLatency: prometheus.NewDesc(
+			server.MetricPrefix+"_latest_relayed_packet_latency_seconds",
+			"Seconds from send to ack of the last relayed packet",
+			labels, nil,
+		),
+		RelayLatency: prometheus.NewDesc(
+			server.MetricPrefix+"_relay_latency_seconds",
+			"Seconds between block times of the packet txs per phase: send_to_recv, recv_to_ack or send_to_ack",
+			append(labels, "phase"), nil,
+		),
+		RelayedPackets: prometheus.NewDesc(
+			server.MetricPrefix+"_relayed_packets_total",
+			"Number of packets acknowledged on the source since tracking started",
+			labels, nil,
+		),
+		TimedOutPackets: prometheus.NewDesc(
+			server.MetricPrefix+"_timed_out_packets_total",
+			"Number of packets timed out on the source since tracking started",
+			labels, nil,
+		),
+		FailedPackets: prometheus.NewDesc(
+			server.MetricPrefix+"_failed_packets_total",
+			"Number of packets whose recv or ack tx failed since tracking started",
+			labels, nil,
+		),
+		ErrorAckPackets: prometheus.NewDesc(
+			server.MetricPrefix+"_error_ack_packets_total",
+			"Number of packets received with an error acknowledgement since tracking started",
+			labels, nil,
+		),
+		WaitingTimeoutPackets: prometheus.NewDesc(
+			server.MetricPrefix+"_waiting_timeout_packets",
+			"Number of timed out packets not timed out on the source yet, their funds stay escrowed",
+			labels, nil,
+		),
+		Degraded: prometheus.NewDesc(
+			server.MetricPrefix+"_packet_tracker_degraded",
+			"1 if tracking ibc packets of the channel fails",
+			labels, nil,
+		),
 	}
 }
 
@@ -148,6 +390,16 @@ func (c *IBCPacketCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.ObservedSucceedSendPacketSequence
 	ch <- c.ObservedSucceedRecvPacketSequence
 	ch <- c.ObservedSucceedAckPacketSequence
+	ch <- c.PendingPackets
+	ch <- c.OldestPendingPacketAge
+	ch <- c.LatestRelayedPacketLatency
+	ch <- c.RelayLatency
+	ch <- c.RelayedPackets
+	ch <- c.TimedOutPackets
+	ch <- c.FailedPackets
+	ch <- c.ErrorAckPackets
+	ch <- c.WaitingTimeoutPackets
+	ch <- c.Degraded
 }
 
 func (c *IBCPacketCollector) Collect(ch chan<- prometheus.Metric) {
@@ -155,12 +407,20 @@ func (c *IBCPacketCollector) Collect(ch chan<- prometheus.Metric) {
 
 	for _, ibcPacket := range resp {
 		labels := []string{
+			ibcPacket.BaseChainId,
 			ibcPacket.Source.ChainId,
 			ibcPacket.Source.Path,
 			ibcPacket.Destination.ChainId,
 			ibcPacket.Destination.Path,
 		}
 
+		ch <- prometheus.MustNewConstMetric(
+			c.Degraded,
+			prometheus.GaugeValue,
+			degraded(ibcPacket.Degraded),
+			labels...,
+		)
+
 		ch <- prometheus.MustNewConstMetric(
 			c.ChannelSequence,
 			prometheus.GaugeValue,
@@ -173,6 +433,71 @@ func (c *IBCPacketCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(ibcPacket.ConsecutiveMissed),
 			labels...,
 		)
+		ch <- prometheus.MustNewConstMetric(
+			c.PendingPackets,
+			prometheus.GaugeValue,
+			float64(ibcPacket.Pending),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.OldestPendingPacketAge,
+			prometheus.GaugeValue,
+			ibcPacket.OldestPendingAge,
+			labels...,
+		)
+		for phase, histogram := range ibcPacket.LatencyHistograms {
+			buckets := make(map[float64]uint64, len(app.LATENCY_BUCKETS))
+			for i, bound := range app.LATENCY_BUCKETS {
+				buckets[bound] = histogram.Buckets[i]
+			}
+
+			ch <- prometheus.MustNewConstHistogram(
+				c.RelayLatency,
+				histogram.Count,
+				histogram.Sum,
+				buckets,
+				append(labels, phase)...,
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.RelayedPackets,
+			prometheus.CounterValue,
+			float64(ibcPacket.Relayed),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.TimedOutPackets,
+			prometheus.CounterValue,
+			float64(ibcPacket.TimedOut),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.FailedPackets,
+			prometheus.CounterValue,
+			float64(ibcPacket.Failed),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.ErrorAckPackets,
+			prometheus.CounterValue,
+			float64(ibcPacket.ErrorAck),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.WaitingTimeoutPackets,
+			prometheus.GaugeValue,
+			float64(ibcPacket.WaitingTimeout),
+			labels...,
+		)
+		if len(ibcPacket.RelayedPackets) > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				c.LatestRelayedPacketLatency,
+				prometheus.GaugeValue,
+				ibcPacket.RelayedPackets[len(ibcPacket.RelayedPackets)-1].Latency,
+				labels...,
+			)
+		}
 
 		for packetType, succeedPacket := range ibcPacket.LatestSucceedPackets {
 			switch packetType {
@@ -201,3 +526,306 @@ func (c *IBCPacketCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 	}
 }
+
+type UnrelayedPacketCollector struct {
+	server *Server
+
+	Health            *prometheus.Desc
+	UnreceivedPackets *prometheus.Desc
+	UnreceivedAcks    *prometheus.Desc
+	OldestSequence    *prometheus.Desc
+	Degraded          *prometheus.Desc
+}
+
+func newUnrelayedPacketCollector(server *Server) *UnrelayedPacketCollector {
+	labels := []string{"base_chain_id", "src_chain_id", "src_path", "dst_chain_id", "dst_path"}
+
+	return &UnrelayedPacketCollector{
+		server: server,
+
+		Health: prometheus.NewDesc(
+			server.MetricPrefix+"_unrelayed_health",
+			"If 1 no packet is stuck longer than the warning time",
+			labels, nil,
+		),
+		UnreceivedPackets: prometheus.NewDesc(
+			server.MetricPrefix+"_unreceived_packets",
+			"Number of packets committed on source but not received on destination",
+			labels, nil,
+		),
+		UnreceivedAcks: prometheus.NewDesc(
+			server.MetricPrefix+"_unreceived_acks",
+			"Number of acknowledgements written on destination but not relayed to source",
+			labels, nil,
+		),
+		OldestSequence: prometheus.NewDesc(
+			server.MetricPrefix+"_oldest_unrelayed_sequence",
+			"Sequence number of the oldest unrelayed packet, 0 if every packet is relayed",
+			labels, nil,
+		),
+		Degraded: prometheus.NewDesc(
+			server.MetricPrefix+"_unrelayed_degraded",
+			"1 if checking unrelayed packets of the channel fails, the other values are of the last success",
+			labels, nil,
+		),
+	}
+}
+
+func (c *UnrelayedPacketCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Health
+	ch <- c.UnreceivedPackets
+	ch <- c.UnreceivedAcks
+	ch <- c.OldestSequence
+	ch <- c.Degraded
+}
+
+func (c *UnrelayedPacketCollector) Collect(ch chan<- prometheus.Metric) {
+	resp := c.server.QueryUnrelayedPackets()
+
+	for _, unrelayedPacket := range resp {
+		labels := []string{
+			unrelayedPacket.BaseChainId,
+			unrelayedPacket.Source.ChainId,
+			unrelayedPacket.Source.Path,
+			unrelayedPacket.Destination.ChainId,
+			unrelayedPacket.Destination.Path,
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Degraded,
+			prometheus.GaugeValue,
+			degraded(unrelayedPacket.Degraded),
+			labels...,
+		)
+
+		var health float64 = 0
+		if unrelayedPacket.Health {
+			health = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Health,
+			prometheus.GaugeValue,
+			health,
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.UnreceivedPackets,
+			prometheus.GaugeValue,
+			float64(len(unrelayedPacket.UnreceivedPackets)),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.UnreceivedAcks,
+			prometheus.GaugeValue,
+			float64(len(unrelayedPacket.UnreceivedAcks)),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.OldestSequence,
+			prometheus.GaugeValue,
+			float64(unrelayedPacket.OldestSequence),
+			labels...,
+		)
+	}
+}
+
+type EndpointCollector struct {
+	server *Server
+
+	Active   *prometheus.Desc
+	Healthy  *prometheus.Desc
+	Latency  *prometheus.Desc
+	Requests *prometheus.Desc
+	Errors   *prometheus.Desc
+	Retries  *prometheus.Desc
+}
+
+func newEndpointCollector(server *Server) *EndpointCollector {
+	labels := []string{"chain_id", "type", "endpoint"}
+
+	return &EndpointCollector{
+		server: server,
+
+		Active: prometheus.NewDesc(
+			server.MetricPrefix+"_endpoint_active",
+			"If 1 requests of the chain are sent through the endpoint",
+			labels, nil,
+		),
+		Healthy: prometheus.NewDesc(
+			server.MetricPrefix+"_endpoint_healthy",
+			"If 1 the endpoint passed the last health check",
+			labels, nil,
+		),
+		Latency: prometheus.NewDesc(
+			server.MetricPrefix+"_endpoint_latency_seconds",
+			"Latency of the endpoint measured by the last health check",
+			labels, nil,
+		),
+		Requests: prometheus.NewDesc(
+			server.MetricPrefix+"_endpoint_requests_total",
+			"Number of requests sent to the endpoint",
+			labels, nil,
+		),
+		Errors: prometheus.NewDesc(
+			server.MetricPrefix+"_endpoint_errors_total",
+			"Number of failed requests sent to the endpoint",
+			labels, nil,
+		),
+		Retries: prometheus.NewDesc(
+			server.MetricPrefix+"_endpoint_retries_total",
+			"Number of requests retried after failing on the endpoint",
+			labels, nil,
+		),
+	}
+}
+
+func (c *EndpointCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Active
+	ch <- c.Healthy
+	ch <- c.Latency
+	ch <- c.Requests
+	ch <- c.Errors
+	ch <- c.Retries
+}
+
+func (c *EndpointCollector) Collect(ch chan<- prometheus.Metric) {
+	resp := c.server.QueryEndpoints()
+
+	for _, endpoint := range resp {
+		labels := []string{
+			endpoint.ChainId,
+			endpoint.Type,
+			endpoint.Host,
+		}
+
+		var active float64 = 0
+		if endpoint.Active {
+			active = 1
+		}
+		var healthy float64 = 0
+		if endpoint.Healthy {
+			healthy = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Active,
+			prometheus.GaugeValue,
+			active,
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.Healthy,
+			prometheus.GaugeValue,
+			healthy,
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.Latency,
+			prometheus.GaugeValue,
+			endpoint.Latency,
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.Requests,
+			prometheus.CounterValue,
+			float64(endpoint.Requests),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.Errors,
+			prometheus.CounterValue,
+			float64(endpoint.Errors),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.Retries,
+			prometheus.CounterValue,
+			float64(endpoint.Retries),
+			labels...,
+		)
+	}
+}
+
+type RelayerCollector struct {
+	server *Server
+
+	Packets        *prometheus.Desc
+	FailedTxs      *prometheus.Desc
+	AverageLatency *prometheus.Desc
+}
+
+func newRelayerCollector(server *Server) *RelayerCollector {
+	labels := []string{"chain_id", "relayer"}
+
+	return &RelayerCollector{
+		server: server,
+
+		Packets: prometheus.NewDesc(
+			server.MetricPrefix+"_relayer_packets_total",
+			"Number of packets relayed by the relayer with successful txs",
+			append(labels, "packet_type"), nil,
+		),
+		FailedTxs: prometheus.NewDesc(
+			server.MetricPrefix+"_relayer_failed_txs_total",
+			"Number of failed txs of the relayer",
+			labels, nil,
+		),
+		AverageLatency: prometheus.NewDesc(
+			server.MetricPrefix+"_relayer_average_latency_seconds",
+			"Average seconds from the previous phase to recv_packet or acknowledge_packet relayed by the relayer",
+			labels, nil,
+		),
+	}
+}
+
+func (c *RelayerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Packets
+	ch <- c.FailedTxs
+	ch <- c.AverageLatency
+}
+
+func (c *RelayerCollector) Collect(ch chan<- prometheus.Metric) {
+	resp := c.server.QueryRelayers()
+
+	for _, relayer := range resp {
+		labels := []string{
+			relayer.ChainId,
+			relayer.Address,
+		}
+
+		packets := map[string]uint64{
+			app.PACKET_STATUS_RECV.String():    relayer.RecvPackets,
+			app.PACKET_STATUS_ACK.String():     relayer.AckPackets,
+			app.PACKET_STATUS_TIMEOUT.String(): relayer.TimeoutPackets,
+		}
+		for packetType, count := range packets {
+			ch <- prometheus.MustNewConstMetric(
+				c.Packets,
+				prometheus.CounterValue,
+				float64(count),
+				append(labels, packetType)...,
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.FailedTxs,
+			prometheus.CounterValue,
+			float64(relayer.FailedTxs),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.AverageLatency,
+			prometheus.GaugeValue,
+			relayer.AverageLatency,
+			labels...,
+		)
+	}
+}
+
+func degraded(degradation *Degradation) float64 {
+	if degradation == nil {
+		return 0
+	}
+	return 1
+}
@@ -15,10 +15,20 @@ func (server *Server) Run() error {
 	r.MustRegister(newIBCInfoCollector(server))
 	r.MustRegister(newClientHealthCollector(server))
 	r.MustRegister(newIBCPacketCollector(server))
+	r.MustRegister(newUnrelayedPacketCollector(server))
+	r.MustRegister(newEndpointCollector(server))
+	r.MustRegister(newRelayerCollector(server))
 
 	server.mux.HandleFunc("/ibc-info", server.getIBCInfo)
 	server.mux.HandleFunc("/client-health", server.getClientHealth)
 	server.mux.HandleFunc("/ibc-packet", server.getIBCPacket)
+	server.mux.HandleFunc("/unrelayed-packets", server.getUnrelayedPackets)
+	server.mux.HandleFunc("/endpoints", server.getEndpoints)
+	server.mux.HandleFunc("/changelog", server.getChangelog)
+	server.mux.HandleFunc("/relayers", server.getRelayers)
+	server.mux.HandleFunc("GET /silences", server.getSilences)
+	server.mux.HandleFunc("POST /silences", server.authorize(server.createSilence))
+	server.mux.HandleFunc("DELETE /silences/{id}", server.authorize(server.deleteSilence))
 	server.mux.Handle("/metrics", promhttp.HandlerFor(r, promhttp.HandlerOpts{}))
 
 	msg := fmt.Sprintf("starting server on %s", server.port)
@@ -13,7 +13,16 @@ type (
 	IBCInfos []IBCInfo
 
 	IBCInfo struct {
-		Updated time.Time `json:"updated"`
+		Updated     time.Time `json:"updated"`
+		BaseChainId string    `json:"base_chain_id"`
+
+		// packets are not tracked, because endpoints of the destination are not found
+		Unmonitored bool `json:"unmonitored"`
+
+		// empty channel state for the connections which are not open, they have no channel
+		ConnectionState string     `json:"connection_state"`
+		ChannelState    string     `json:"channel_state"`
+		FlushingSince   *time.Time `json:"flushing_since,omitempty"`
 
 		Source      IBC `json:"source"`
 		Destination IBC `json:"destination"`
@@ -24,7 +33,11 @@ type (
 type (
 	ClientHealths []ClientHealth
 	ClientHealth  struct {
+		BaseChainId string `json:"base_chain_id"`
+
 		Health        bool      `json:"health"`
+		Status        string    `json:"status"`
+		ClientType    string    `json:"client_type"`
 		ClientUpdated time.Time `json:"client_updated"`
 
 		Source      string `json:"source"`
@@ -32,6 +45,15 @@ type (
 
 		ClientId       string  `json:"client_id"`
 		TrustingPeriod float64 `json:"trusting_period"`
+
+		ExpiresAt          *time.Time `json:"expires_at"`
+		TimeToExpiry       float64    `json:"time_to_expiry"`
+		TimeSinceUpdate    float64    `json:"time_since_update"`
+		UpdateInterval     float64    `json:"update_interval"`
+		NextExpectedUpdate *time.Time `json:"next_expected_update"`
+		ExpiryForecast     bool       `json:"expiry_forecast"`
+
+		Degraded *Degradation `json:"degraded,omitempty"`
 	}
 )
 
@@ -39,7 +61,8 @@ type (
 type (
 	IBCPackets []IBCPacket
 	IBCPacket  struct {
-		Updated time.Time `json:"updated"`
+		Updated     time.Time `json:"updated"`
+		BaseChainId string    `json:"base_chain_id"`
 
 		Health bool `json:"health"`
 
@@ -47,8 +70,46 @@ type (
 		Destination IBC `json:"destination"`
 
 		Sequence             uint64         `json:"sequence"`
+		SequenceSource       string         `json:"sequence_source"`
 		ConsecutiveMissed    uint64         `json:"consecutive_missed"`
 		LatestSucceedPackets SucceedPackets `json:"latest_succeed_packets"`
+
+		Relayed  uint64 `json:"relayed"`
+		TimedOut uint64 `json:"timed_out"`
+		Failed   uint64 `json:"failed"`
+		ErrorAck uint64 `json:"error_ack"`
+
+		Pending          int              `json:"pending"`
+		OldestPendingAge float64          `json:"oldest_pending_age"`
+		WaitingTimeout   int              `json:"waiting_timeout"`
+		InFlightPackets  []InFlightPacket `json:"in_flight_packets"`
+		RelayedPackets   []RelayedPacket  `json:"relayed_packets"`
+		// phase => LatencyPercentiles of the relayed packets above
+		Latency map[string]LatencyPercentiles `json:"latency"`
+
+		// exported only as prometheus histograms
+		LatencyHistograms app.LatencyHistograms `json:"-"`
+
+		Degraded *Degradation `json:"degraded,omitempty"`
+	}
+	InFlightPacket struct {
+		Sequence   uint64     `json:"sequence"`
+		WaitingFor string     `json:"waiting_for"`
+		Sent       time.Time  `json:"sent"`
+		Age        float64    `json:"age"`
+		TimedOut   *time.Time `json:"timed_out,omitempty"`
+	}
+	RelayedPacket struct {
+		Sequence    uint64  `json:"sequence"`
+		Latency     float64 `json:"latency"`
+		RecvLatency float64 `json:"recv_latency"`
+		AckLatency  float64 `json:"ack_latency"`
+		RecvRelayer string  `json:"recv_relayer,omitempty"`
+		AckRelayer  string  `json:"ack_relayer,omitempty"`
+	}
+	LatencyPercentiles struct {
+		P50 float64 `json:"p50"`
+		P95 float64 `json:"p95"`
 	}
 	// PakcetType => SucceedPacket
 	SucceedPackets map[string]SucceedPacket
@@ -56,20 +117,134 @@ type (
 		Hash     string `json:"hash"`
 		Sequence uint64 `json:"sequence"`
 		Data     string `json:"data"`
+		AckError string `json:"ack_error,omitempty"`
+	}
+)
+
+// response for "/unrelayed-packets"
+type (
+	UnrelayedPackets []UnrelayedPacket
+	UnrelayedPacket  struct {
+		Updated     time.Time `json:"updated"`
+		BaseChainId string    `json:"base_chain_id"`
+
+		Health bool `json:"health"`
+
+		Source      IBC `json:"source"`
+		Destination IBC `json:"destination"`
+
+		UnreceivedPackets []uint64  `json:"unreceived_packets"`
+		UnreceivedAcks    []uint64  `json:"unreceived_acks"`
+		OldestSequence    uint64    `json:"oldest_sequence"`
+		OldestSince       time.Time `json:"oldest_since"`
+
+		Degraded *Degradation `json:"degraded,omitempty"`
+	}
+)
+
+// response for "/endpoints"
+type (
+	Endpoints []Endpoint
+	Endpoint  struct {
+		ChainId string `json:"chain_id"`
+		Type    string `json:"type"`
+		Host    string `json:"host"`
+
+		Active  bool    `json:"active"`
+		Healthy bool    `json:"healthy"`
+		Latency float64 `json:"latency"`
+
+		Requests uint64 `json:"requests"`
+		Errors   uint64 `json:"errors"`
+		Retries  uint64 `json:"retries"`
 	}
 )
 
+// response for "/changelog"
+type (
+	Changelog      []TopologyChange
+	TopologyChange struct {
+		Time        time.Time `json:"time"`
+		BaseChainId string    `json:"base_chain_id"`
+
+		Type string `json:"type"`
+
+		// only the fields related with the change are set
+		Source      IBC    `json:"source"`
+		Destination string `json:"destination"`
+
+		// states of the channel before and after the change
+		PrevState string `json:"prev_state,omitempty"`
+		State     string `json:"state,omitempty"`
+	}
+)
+
+// response for "/relayers"
+type (
+	Relayers []Relayer
+	Relayer  struct {
+		ChainId string `json:"chain_id"`
+		Address string `json:"address"`
+		Memo    string `json:"memo"`
+
+		RecvPackets    uint64 `json:"recv_packets"`
+		AckPackets     uint64 `json:"ack_packets"`
+		TimeoutPackets uint64 `json:"timeout_packets"`
+		FailedTxs      uint64 `json:"failed_txs"`
+
+		AverageLatency float64   `json:"average_latency"`
+		LastRelayed    time.Time `json:"last_relayed"`
+
+		// channels relayed by the relayer, formatted as IBCPacketTracker.String
+		Paths []string `json:"paths"`
+	}
+)
+
+// error of a failing check or tracker, which is retried with backoff
+type Degradation struct {
+	Error    string    `json:"error"`
+	Since    time.Time `json:"since"`
+	Failures uint64    `json:"failures"`
+}
+
+func newDegradation(degradation *app.Degradation) *Degradation {
+	if degradation == nil {
+		return nil
+	}
+
+	return &Degradation{
+		Error:    degradation.Error,
+		Since:    degradation.Since,
+		Failures: degradation.Failures,
+	}
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
 type IBC struct {
 	Path string `json:"path"`
 
 	ChainId      string
 	ClientId     string
+	ClientType   string `json:",omitempty"`
 	ConnectionId string
 	ChannelId    string
 	PortId       string
 }
 
 func newIBC(chainId, clientId, connectionId, channelId, portId string) IBC {
+	// IBC v2 routes packets from client to client without connection and channel
+	if connectionId == "" {
+		return IBC{
+			Path: fmt.Sprintf("%s(%s)", chainId, clientId),
+
+			ChainId:  chainId,
+			ClientId: clientId,
+		}
+	}
+
 	path := fmt.Sprintf(
 		"%s(%s/%s/%s/%s)",
 		chainId,
@@ -87,17 +262,25 @@ func newIBC(chainId, clientId, connectionId, channelId, portId string) IBC {
 }
 
 type Server struct {
-	Store        *app.Store
-	mux          *http.ServeMux
-	port         string
+	Store *app.Store
+	// statuses of rpc and grpc endpoints of every chain
+	EndpointStatuses func() app.EndpointStatuses
+
+	mux  *http.ServeMux
+	port string
+	// required by the handlers changing silences, empty disables them
+	adminToken   string
 	MetricPrefix string
 }
 
-func NewServer(store *app.Store, port int, prefix string) *Server {
+func NewServer(store *app.Store, endpointStatuses func() app.EndpointStatuses, port int, adminToken string, prefix string) *Server {
 	server := Server{
-		Store:        store,
+		Store:            store,
+		EndpointStatuses: endpointStatuses,
+
 		mux:          http.NewServeMux(),
 		port:         fmt.Sprintf(":%d", port),
+		adminToken:   adminToken,
 		MetricPrefix: prefix,
 	}
 
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var BOLT_BUCKET = []byte("state")
+
+// BoltStore keeps values in an embedded BoltDB file.
+// Each Put is a transaction which only rewrites its own key, so large trackers don't rewrite every value.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create state directory: %s", path)
+	}
+
+	// the file is locked, so another process can't open the same state
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open state db: %s", path)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(BOLT_BUCKET)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to create state bucket: %s", path)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(key string, v any) (bool, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// the value is valid only in the transaction
+		value = append(value, tx.Bucket(BOLT_BUCKET).Get([]byte(key))...)
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to read state: %s", key)
+	}
+	if value == nil {
+		return false, nil
+	}
+
+	err = json.Unmarshal(value, v)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to decode state: %s", key)
+	}
+
+	return true, nil
+}
+
+func (s *BoltStore) Put(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode state: %s", key)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(BOLT_BUCKET).Put([]byte(key), value)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to write state: %s", key)
+	}
+
+	return nil
+}
+
+func (s *BoltStore) Close() error {
+	err := s.db.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close state db")
+	}
+
+	return nil
+}
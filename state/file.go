@@ -0,0 +1,120 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FileStore keeps every value in a single JSON file on disk, which is easy to inspect and edit by hand.
+// The whole file is rewritten atomically on each Put, so BoltStore is preferred for many trackers.
+type FileStore struct {
+	mutex  sync.Mutex
+	path   string
+	values map[string]json.RawMessage
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:   path,
+		values: make(map[string]json.RawMessage),
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create state directory: %s", path)
+	}
+
+	f, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read state file: %s", path)
+	}
+
+	if len(f) == 0 {
+		return s, nil
+	}
+
+	err = json.Unmarshal(f, &s.values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode state file: %s", path)
+	}
+
+	return s, nil
+}
+
+func (s *FileStore) Get(key string, v any) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	value, ok := s.values[key]
+	if !ok {
+		return false, nil
+	}
+
+	err := json.Unmarshal(value, v)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to decode state: %s", key)
+	}
+
+	return true, nil
+}
+
+func (s *FileStore) Put(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode state: %s", key)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values[key] = value
+
+	return s.flush()
+}
+
+func (s *FileStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.flush()
+}
+
+// write all values to a temp file and rename it, so a crash never leaves a partial file
+func (s *FileStore) flush() error {
+	f, err := json.Marshal(s.values)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode state file")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temp state file: %s", s.path)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(f); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to write state file: %s", s.path)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to sync state file: %s", s.path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close state file: %s", s.path)
+	}
+
+	err = os.Rename(tmp.Name(), s.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to replace state file: %s", s.path)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package state
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryStore keeps values only while the process is alive
+type MemoryStore struct {
+	mutex  sync.Mutex
+	values map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		values: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) Get(key string, v any) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	value, ok := s.values[key]
+	if !ok {
+		return false, nil
+	}
+
+	err := json.Unmarshal(value, v)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to decode state: %s", key)
+	}
+
+	return true, nil
+}
+
+func (s *MemoryStore) Put(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode state: %s", key)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values[key] = value
+
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	BACKEND_MEMORY = "memory"
+	BACKEND_FILE   = "file"
+	BACKEND_BOLT   = "bolt"
+)
+
+// StateStore is a key-value store for values which should survive restarts.
+// Values are encoded as JSON, so only exported fields are stored.
+type StateStore interface {
+	// Get decodes the value of key into v, returns false if key is not found
+	Get(key string, v any) (bool, error)
+	Put(key string, v any) error
+	Close() error
+}
+
+func New(backend, path string) (StateStore, error) {
+	switch backend {
+	case "", BACKEND_MEMORY:
+		return NewMemoryStore(), nil
+	case BACKEND_FILE:
+		if path == "" {
+			return nil, errors.New("missing state file path in config file")
+		}
+		return NewFileStore(path)
+	case BACKEND_BOLT:
+		if path == "" {
+			return nil, errors.New("missing state db path in config file")
+		}
+		return NewBoltStore(path)
+	default:
+		msg := fmt.Sprintf("unknown state backend: %s", backend)
+		return nil, errors.New(msg)
+	}
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+type testValue struct {
+	Sequence uint64
+	Channels []string
+}
+
+func TestStateStore(t *testing.T) {
+	tests := []struct {
+		backend string
+		// values survive reopening the store
+		persistent bool
+	}{
+		{backend: BACKEND_MEMORY, persistent: false},
+		{backend: BACKEND_FILE, persistent: true},
+		{backend: BACKEND_BOLT, persistent: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.backend, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data", "state")
+			expected := testValue{Sequence: 10, Channels: []string{"channel-0", "channel-1"}}
+
+			store, err := New(test.backend, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var value testValue
+			found, err := store.Get("tracker", &value)
+			if err != nil || found {
+				t.Fatalf("expected not found, got %t %v", found, err)
+			}
+
+			err = store.Put("tracker", expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found, err = store.Get("tracker", &value)
+			if err != nil || !found || value.Sequence != expected.Sequence || len(value.Channels) != len(expected.Channels) {
+				t.Fatalf("expected %+v, got %+v %t %v", expected, value, found, err)
+			}
+
+			err = store.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reopened, err := New(test.backend, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reopened.Close()
+
+			value = testValue{}
+			found, err = reopened.Get("tracker", &value)
+			if err != nil || found != test.persistent {
+				t.Fatalf("expected found %t, got %t %v", test.persistent, found, err)
+			}
+			if test.persistent && value.Sequence != expected.Sequence {
+				t.Fatalf("expected %+v, got %+v", expected, value)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+
+		backend string
+		path    string
+	}{
+		{name: "file without path", backend: BACKEND_FILE},
+		{name: "bolt without path", backend: BACKEND_BOLT},
+		{name: "unknown backend", backend: "redis", path: "state"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.backend, test.path)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}